@@ -1,3 +1,5 @@
 module learngo
 
 go 1.18
+
+require gopkg.in/yaml.v3 v3.0.1
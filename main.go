@@ -45,8 +45,8 @@ func main() {
 
 	if len(diff.Changed) > 0 {
 		fmt.Println("变更:")
-		for path, change := range diff.Changed {
-			fmt.Printf("  * %s: %s\n", path, change)
+		for _, path := range diff.ChangedPaths() {
+			fmt.Printf("  * %s: %s\n", path, diff.Changed[path])
 		}
 	}
 
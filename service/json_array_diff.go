@@ -0,0 +1,435 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// ArrayDiffMode 表示数组的比较策略
+type ArrayDiffMode int
+
+const (
+	// ArrayDiffPositional 按下标逐一比较数组元素（默认策略）
+	ArrayDiffPositional ArrayDiffMode = iota
+	// ArrayDiffLCS 基于最长公共子序列比较数组，中间插入或删除的元素只报告一次新增或移除
+	ArrayDiffLCS
+)
+
+// arrayKeyRule 描述一条按标识字段匹配数组元素的规则
+type arrayKeyRule struct {
+	pattern string   // 数组元素的路径模式，例如 "employees[*]"
+	fields  []string // 标识字段，多个字段组成组合键
+}
+
+// compareArrays 根据配置选择数组的比较策略
+func (d *jsonDiffer) compareArrays(path string, a1, a2 []interface{}) {
+	if fields := d.config.arrayKeyFields(path); fields != nil {
+		d.compareArraysKeyed(path, a1, a2, fields)
+		return
+	}
+	if d.config.compiled().unordered.matches(path) {
+		d.compareArraysUnordered(path, a1, a2)
+		return
+	}
+
+	if d.config.lcsArrays() {
+		d.compareArraysLCS(path, a1, a2)
+		return
+	}
+	d.compareArraysPositional(path, a1, a2)
+}
+
+// lcsArrays 判断未按标识字段配对、也不忽略顺序的数组是否按 LCS 策略比较；
+// 按下标比较无法识别数组内的移动，启用移动检测时同样使用 LCS 策略
+func (c *diffConfig) lcsArrays() bool {
+	return c.arrayMode == ArrayDiffLCS || c.moves
+}
+
+// arrayKeyFields 返回指定路径下数组元素的标识字段，没有匹配的规则时返回 nil
+func (c *diffConfig) arrayKeyFields(path string) []string {
+	if i := c.compiled().arrayKeys.first(path); i >= 0 {
+		return c.arrayKeys[i].fields
+	}
+	return nil
+}
+
+// arrayPairs 按与 compareArrays 相同的策略为两个数组中需要逐个比较的元素配对，未配对的元素视为被移除或新增；
+// LCS 策略下不在公共子序列中但值相同的元素也参与配对。eq 为判断元素相等的规则，为 nil 时只有结构相同的元素相等。
+// 生成补丁和三方合并用它对齐数组元素，它们精确处理每个值，传入 nil
+func (c *diffConfig) arrayPairs(path string, a1, a2 []interface{}, eq elementEqual) [][2]int {
+	if fields := c.arrayKeyFields(path); fields != nil {
+		return keyedPairs(a1, a2, fields, eq)
+	}
+	if c.compiled().unordered.matches(path) {
+		return equalPairs(a1, a2, nil, nil, eq)
+	}
+
+	if !c.lcsArrays() {
+		var pairs [][2]int
+		for i := 0; i < len(a1) && i < len(a2); i++ {
+			pairs = append(pairs, [2]int{i, i})
+		}
+		return pairs
+	}
+
+	// LCS 策略：公共子序列和值相同的元素原样保留，其余元素在区间内按顺序配对
+	anchors := lcsPairs(a1, a2, eq)
+	common1, common2 := pairedMarks(len(a1), len(a2), anchors)
+	moves := equalPairs(a1, a2, common1, common2, eq)
+	skip1, skip2 := pairedMarks(len(a1), len(a2), append(anchors, moves...))
+	pairs := append(anchors, moves...)
+	forEachGap(anchors, len(a1), len(a2), skip1, skip2, func(gap1, gap2 []int) {
+		for k := 0; k < len(gap1) && k < len(gap2); k++ {
+			pairs = append(pairs, [2]int{gap1[k], gap2[k]})
+		}
+	})
+	return pairs
+}
+
+// elementEqual 判断两个数组元素是否相等，j 为元素在第二个数组中的下标
+type elementEqual func(v1, v2 interface{}, j int) bool
+
+// elementEqual 返回按本次比较的规则判断 path 下数组元素是否相等的函数：忽略和包含规则、比较器、容差
+// 以及元素内部的数组策略都会生效。配置中没有这些规则时结构相同即相等，返回 nil
+func (d *jsonDiffer) elementEqual(path string) elementEqual {
+	c := d.config
+	if d.ignore.empty() && d.include.empty() && len(c.comparators) == 0 && len(c.typeComparators) == 0 &&
+		len(c.tolerances) == 0 && len(c.arrayKeys) == 0 && len(c.unordered) == 0 {
+		return nil
+	}
+	return func(v1, v2 interface{}, j int) bool {
+		// 用共享规则的子比较器比较两个元素，记录第一处差异后立即停止
+		sub := &jsonDiffer{ctx: d.ctx, config: c, ignore: d.ignore, include: d.include}
+		sub.sink = ChangeSinkFunc(func(Change) error {
+			return ErrStopComparison
+		})
+		sub.compareValues(d.indexPath(path, j), v1, v2)
+		return sub.recorded == 0 && sub.err == nil
+	}
+}
+
+// compareArraysKeyed 按标识字段配对数组元素后比较，配对元素的变更使用第二个数组中的下标作为路径，
+// 未配对的元素整体报告为移除或新增；标识字段重复时按出现顺序依次配对。
+// 不是对象或缺少标识字段的元素只与另一侧同样没有标识、值相同的元素配对，其余的报告为移除或新增
+func (d *jsonDiffer) compareArraysKeyed(path string, a1, a2 []interface{}, fields []string) {
+	pairs := keyedPairs(a1, a2, fields, d.elementEqual(path))
+
+	// 启用移动检测时，相对顺序发生变化的配对元素报告为移动
+	var inOrder []bool
+	if d.config.moves {
+		inOrder = increasingPairs(pairs)
+	}
+	for p, pair := range pairs {
+		if inOrder != nil && !inOrder[p] {
+			d.recordMoved(d.indexPath(path, pair[0]), d.indexPath(path, pair[1]), a1[pair[0]], a2[pair[1]])
+		}
+		d.compareValues(d.indexPath(path, pair[1]), a1[pair[0]], a2[pair[1]])
+	}
+
+	d.recordUnpaired(path, a1, a2, pairs)
+}
+
+// keyedPairs 按标识字段为两个数组的元素配对，返回按第二个数组下标排列的配对；
+// 标识重复时按出现顺序依次配对，没有标识的元素只与另一侧没有标识且值相同（按 eq 判断，见 equalPairs）的元素配对
+func keyedPairs(a1, a2 []interface{}, fields []string, eq elementEqual) [][2]int {
+	keys1 := elementKeys(a1, fields)
+	keys2 := elementKeys(a2, fields)
+
+	// 有标识的元素不参与按值配对
+	keyed1 := make([]bool, len(a1))
+	for i, k := range keys1 {
+		keyed1[i] = k != ""
+	}
+	keyed2 := make([]bool, len(a2))
+	for j, k := range keys2 {
+		keyed2[j] = k != ""
+	}
+	pairs := equalPairs(a1, a2, keyed1, keyed2, eq)
+
+	// 记录第一个数组中每个标识对应的下标队列
+	pending := make(map[string][]int, len(keys1))
+	for i, k := range keys1 {
+		if k != "" {
+			pending[k] = append(pending[k], i)
+		}
+	}
+	for j, k := range keys2 {
+		if queue := pending[k]; k != "" && len(queue) > 0 {
+			pending[k] = queue[1:]
+			pairs = append(pairs, [2]int{queue[0], j})
+		}
+	}
+	sortPairs(pairs)
+	return pairs
+}
+
+// sortPairs 把配对按第二个数组的下标排列
+func sortPairs(pairs [][2]int) {
+	sort.Slice(pairs, func(p, q int) bool {
+		return pairs[p][1] < pairs[q][1]
+	})
+}
+
+// elementKeys 计算数组中每个元素的标识，元素不是对象或缺少标识字段时标识为空字符串
+func elementKeys(values []interface{}, fields []string) []string {
+	keys := make([]string, len(values))
+	parts := make([]interface{}, len(fields))
+	for i, v := range values {
+		keys[i] = elementKey(v, fields, parts)
+	}
+	return keys
+}
+
+// elementKey 计算一个元素的标识，parts 为长度与 fields 相同的缓冲区
+func elementKey(v interface{}, fields []string, parts []interface{}) string {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	for f, field := range fields {
+		part, exists := obj[field]
+		if !exists {
+			return ""
+		}
+		parts[f] = part
+	}
+	// 编码结果至少包含方括号，不会与表示没有标识的空字符串混淆
+	encoded, err := json.Marshal(parts)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// compareArraysUnordered 把两个数组当作多重集合比较，第一个数组中未能配对的元素报告为移除（使用原下标），
+// 第二个数组中未能配对的元素报告为新增（使用新下标），相同元素出现多次时按次数配对
+func (d *jsonDiffer) compareArraysUnordered(path string, a1, a2 []interface{}) {
+	d.recordUnpaired(path, a1, a2, equalPairs(a1, a2, nil, nil, d.elementEqual(path)))
+}
+
+// equalPairs 为两个数组中值相同的元素配对（skip1 和 skip2 中标记的元素不参与），
+// 返回按第二个数组下标排列的配对；相同元素出现多次时按出现顺序依次配对。
+// 先配对结构相同的元素，eq 不为 nil 时再按 eq 为剩余的元素依次配对
+func equalPairs(a1, a2 []interface{}, skip1, skip2 []bool, eq elementEqual) [][2]int {
+	// 按结构哈希分组记录第一个数组中尚未配对的元素下标
+	pending := make(map[uint64][]int, len(a1))
+	for i, v := range a1 {
+		if skip1 == nil || !skip1[i] {
+			h := hashValue(v)
+			pending[h] = append(pending[h], i)
+		}
+	}
+
+	var pairs [][2]int
+	for j, v := range a2 {
+		if skip2 != nil && skip2[j] {
+			continue
+		}
+		h := hashValue(v)
+		queue := pending[h]
+		for q, i := range queue {
+			if reflect.DeepEqual(a1[i], v) {
+				pending[h] = append(queue[:q:q], queue[q+1:]...)
+				pairs = append(pairs, [2]int{i, j})
+				break
+			}
+		}
+	}
+	if eq == nil {
+		return pairs
+	}
+
+	// 结构不同但按比较规则相等的元素逐对比较后配对
+	paired1, paired2 := pairedMarks(len(a1), len(a2), pairs)
+	for j, v := range a2 {
+		if paired2[j] || (skip2 != nil && skip2[j]) {
+			continue
+		}
+		for i := range a1 {
+			if !paired1[i] && (skip1 == nil || !skip1[i]) && eq(a1[i], v, j) {
+				paired1[i] = true
+				pairs = append(pairs, [2]int{i, j})
+				break
+			}
+		}
+	}
+	sortPairs(pairs)
+	return pairs
+}
+
+// recordUnpaired 把未参与配对的元素报告为移除（使用原下标）或新增（使用新下标）
+func (d *jsonDiffer) recordUnpaired(path string, a1, a2 []interface{}, pairs [][2]int) {
+	paired1, paired2 := pairedMarks(len(a1), len(a2), pairs)
+	for i := range a1 {
+		if !paired1[i] {
+			d.recordRemoved(d.indexPath(path, i), a1[i])
+		}
+	}
+	for j := range a2 {
+		if !paired2[j] {
+			d.recordAdded(d.indexPath(path, j), a2[j])
+		}
+	}
+}
+
+// pairedMarks 标记两个数组中已参与配对的元素
+func pairedMarks(n1, n2 int, pairs [][2]int) ([]bool, []bool) {
+	paired1 := make([]bool, n1)
+	paired2 := make([]bool, n2)
+	for _, p := range pairs {
+		paired1[p[0]] = true
+		paired2[p[1]] = true
+	}
+	return paired1, paired2
+}
+
+// compareArraysPositional 按下标逐一比较两个数组
+func (d *jsonDiffer) compareArraysPositional(path string, a1, a2 []interface{}) {
+	// 如果数组长度不同，记录长度变更
+	if len(a1) != len(a2) {
+		d.record(Change{
+			Kind:     ChangeLengthChanged,
+			Path:     path,
+			OldValue: len(a1),
+			NewValue: len(a2),
+			OldType:  JSONArray,
+			NewType:  JSONArray,
+		})
+	}
+
+	// 比较对应位置的元素（比较到较短数组的长度）
+	minLen := len(a1)
+	if len(a2) < minLen {
+		minLen = len(a2)
+	}
+
+	for i := 0; i < minLen; i++ {
+		d.compareValues(d.indexPath(path, i), a1[i], a2[i])
+	}
+
+	// 处理数组长度不同的情况：第一个数组更长时多余元素为移除，第二个数组更长时多余元素为新增
+	for i := minLen; i < len(a1); i++ {
+		d.recordRemoved(d.indexPath(path, i), a1[i])
+	}
+	for i := minLen; i < len(a2); i++ {
+		d.recordAdded(d.indexPath(path, i), a2[i])
+	}
+}
+
+// compareArraysLCS 基于最长公共子序列比较两个数组
+// 公共子序列中的元素视为未变化；相邻公共元素之间的区间内，两侧元素按顺序配对后递归比较，
+// 多出的元素在第一个数组中报告为移除（使用原下标），在第二个数组中报告为新增（使用新下标）。
+// 配对元素的变更使用第二个数组中的下标作为路径。
+// 启用移动检测时，先把不在公共子序列中、但两侧值相同的元素识别为移动，再处理剩余的区间。
+func (d *jsonDiffer) compareArraysLCS(path string, a1, a2 []interface{}) {
+	pairs := lcsPairs(a1, a2, d.elementEqual(path))
+
+	var moved1, moved2 []bool
+	if d.config.moves {
+		moved1, moved2 = d.detectArrayMoves(path, a1, a2, pairs)
+	}
+
+	forEachGap(pairs, len(a1), len(a2), moved1, moved2, func(gap1, gap2 []int) {
+		d.compareArrayGap(path, a1, a2, gap1, gap2)
+	})
+}
+
+// forEachGap 依次处理相邻公共元素之间的区间，gap1 和 gap2 为区间内未被跳过的元素在各自数组中的下标
+func forEachGap(pairs [][2]int, n1, n2 int, skip1, skip2 []bool, fn func(gap1, gap2 []int)) {
+	i, j := 0, 0
+	for _, p := range append(pairs[:len(pairs):len(pairs)], [2]int{n1, n2}) {
+		var gap1, gap2 []int
+		for ; i < p[0]; i++ {
+			if skip1 == nil || !skip1[i] {
+				gap1 = append(gap1, i)
+			}
+		}
+		for ; j < p[1]; j++ {
+			if skip2 == nil || !skip2[j] {
+				gap2 = append(gap2, j)
+			}
+		}
+		fn(gap1, gap2)
+		i, j = p[0]+1, p[1]+1
+	}
+}
+
+// compareArrayGap 比较两个公共元素之间的区间，gap1 和 gap2 为区间内元素在各自数组中的下标
+func (d *jsonDiffer) compareArrayGap(path string, a1, a2 []interface{}, gap1, gap2 []int) {
+	n := len(gap1)
+	if len(gap2) < n {
+		n = len(gap2)
+	}
+	for k := 0; k < n; k++ {
+		d.compareValues(d.indexPath(path, gap2[k]), a1[gap1[k]], a2[gap2[k]])
+	}
+	for _, i := range gap1[n:] {
+		d.recordRemoved(d.indexPath(path, i), a1[i])
+	}
+	for _, j := range gap2[n:] {
+		d.recordAdded(d.indexPath(path, j), a2[j])
+	}
+}
+
+// lcsPairs 计算两个数组的最长公共子序列，返回按顺序排列的匹配下标对；
+// 结构相同的元素视为相等，eq 不为 nil 时按 eq 判断其余元素是否相等
+func lcsPairs(a1, a2 []interface{}, eq elementEqual) [][2]int {
+	h1 := hashValues(a1)
+	h2 := hashValues(a2)
+	equal := func(i, j int) bool {
+		if h1[i] == h2[j] && reflect.DeepEqual(a1[i], a2[j]) {
+			return true
+		}
+		return eq != nil && eq(a1[i], a2[j], j)
+	}
+
+	// 去掉公共前缀和公共后缀，缩小动态规划的规模
+	prefix := 0
+	for prefix < len(a1) && prefix < len(a2) && equal(prefix, prefix) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a1)-prefix && suffix < len(a2)-prefix && equal(len(a1)-1-suffix, len(a2)-1-suffix) {
+		suffix++
+	}
+
+	n, m := len(a1)-prefix-suffix, len(a2)-prefix-suffix
+	// table[i][j] 表示 a1[prefix+i:] 与 a2[prefix+j:] 的最长公共子序列长度
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if equal(prefix+i, prefix+j) {
+				table[i][j] = table[i+1][j+1] + 1
+			} else if table[i+1][j] >= table[i][j+1] {
+				table[i][j] = table[i+1][j]
+			} else {
+				table[i][j] = table[i][j+1]
+			}
+		}
+	}
+
+	pairs := make([][2]int, 0, prefix+suffix+table[0][0])
+	for k := 0; k < prefix; k++ {
+		pairs = append(pairs, [2]int{k, k})
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case equal(prefix+i, prefix+j):
+			pairs = append(pairs, [2]int{prefix + i, prefix + j})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		pairs = append(pairs, [2]int{prefix + n + k, prefix + m + k})
+	}
+	return pairs
+}
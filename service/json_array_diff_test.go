@@ -0,0 +1,313 @@
+package service
+
+import (
+	"testing"
+)
+
+// containsPath 检查路径列表中是否包含指定路径
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// TestCompareJSON_LCSInsertAtHead 测试LCS模式下在数组头部插入元素只报告一次新增
+func TestCompareJSON_LCSInsertAtHead(t *testing.T) {
+	service := NewJSONDiffService(WithArrayDiffMode(ArrayDiffLCS))
+	json1 := `{"hobbies":["reading","swimming","coding"]}`
+	json2 := `{"hobbies":["cycling","reading","swimming","coding"]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("LCS模式比较JSON时出错: %v", err)
+		return
+	}
+
+	if len(diff.Added) != 1 || diff.Added[0] != "hobbies[0]" {
+		t.Errorf("预期只新增'hobbies[0]'，但实际为: %v", diff.Added)
+	}
+	if len(diff.Removed) > 0 {
+		t.Errorf("预期没有移除字段，但实际有: %v", diff.Removed)
+	}
+	if len(diff.Changed) > 0 {
+		t.Errorf("预期没有变更字段，但实际有: %v", diff.Changed)
+	}
+}
+
+// TestCompareJSON_LCSRemoveInMiddle 测试LCS模式下删除数组中间元素使用原下标报告移除
+func TestCompareJSON_LCSRemoveInMiddle(t *testing.T) {
+	service := NewJSONDiffService(WithArrayDiffMode(ArrayDiffLCS))
+	json1 := `{"items":[{"id":1},{"id":2},{"id":3},{"id":4}]}`
+	json2 := `{"items":[{"id":1},{"id":3},{"id":4}]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("LCS模式比较JSON时出错: %v", err)
+		return
+	}
+
+	if len(diff.Removed) != 1 || diff.Removed[0] != "items[1]" {
+		t.Errorf("预期只移除'items[1]'，但实际为: %v", diff.Removed)
+	}
+	if len(diff.Added) > 0 || len(diff.Changed) > 0 {
+		t.Errorf("预期没有新增和变更，但实际新增: %v，变更: %v", diff.Added, diff.Changed)
+	}
+}
+
+// TestCompareJSON_LCSReplaceElement 测试LCS模式下替换的元素会配对后递归比较
+func TestCompareJSON_LCSReplaceElement(t *testing.T) {
+	service := NewJSONDiffService(WithArrayDiffMode(ArrayDiffLCS))
+	json1 := `{"hobbies":["reading","swimming","coding"]}`
+	json2 := `{"hobbies":["reading","cycling","coding","hiking"]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("LCS模式比较JSON时出错: %v", err)
+		return
+	}
+
+	if _, exists := diff.Changed["hobbies[1]"]; !exists || len(diff.Changed) != 1 {
+		t.Errorf("预期只有'hobbies[1]'发生变更，但实际为: %v", diff.Changed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "hobbies[3]" {
+		t.Errorf("预期只新增'hobbies[3]'，但实际为: %v", diff.Added)
+	}
+}
+
+// TestCompareJSON_LCSWithIgnore 测试LCS模式下忽略路径依然生效
+func TestCompareJSON_LCSWithIgnore(t *testing.T) {
+	service := NewJSONDiffService(WithArrayDiffMode(ArrayDiffLCS))
+	json1 := `{"users":[{"name":"Alice","updated":"1"},{"name":"Bob","updated":"2"}]}`
+	json2 := `{"users":[{"name":"Alice","updated":"3"},{"name":"Bob","updated":"4"},{"name":"Carol","updated":"0"}]}`
+
+	diff, err := service.CompareJSONWithIgnore(json1, json2, []string{"users[*].updated"})
+	if err != nil {
+		t.Errorf("LCS模式比较JSON时出错: %v", err)
+		return
+	}
+
+	// 元素因忽略字段不同而无法整体匹配，按区间配对后只应剩下被忽略的差异
+	for path := range diff.Changed {
+		t.Errorf("预期没有变更字段，但实际有: %s", path)
+	}
+	if !containsPath(diff.Added, "users[2]") {
+		t.Errorf("预期新增'users[2]'，但实际为: %v", diff.Added)
+	}
+}
+
+// TestCompareJSON_LCSWithIgnoreHeadInsert 测试元素只在被忽略的字段上不同时，LCS 仍然把它们作为公共元素，
+// 在开头插入元素不会让后面的元素错位比较
+func TestCompareJSON_LCSWithIgnoreHeadInsert(t *testing.T) {
+	service := NewJSONDiffService(WithArrayDiffMode(ArrayDiffLCS), WithIgnorePaths("u[*].t"))
+	json1 := `{"u":[{"n":"a","t":1},{"n":"b","t":2}]}`
+	json2 := `{"u":[{"n":"x","t":0},{"n":"a","t":3},{"n":"b","t":4}]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("LCS模式比较JSON时出错: %v", err)
+	}
+	if len(diff.Changes) != 1 || diff.Changes[0].Kind != ChangeAdded || diff.Changes[0].Path != "u[0]" {
+		t.Errorf("预期只新增'u[0]'，但实际为: %+v", diff.Changes)
+	}
+}
+
+// TestCompareJSON_PositionalModeIsDefault 测试默认仍使用按下标比较的策略
+func TestCompareJSON_PositionalModeIsDefault(t *testing.T) {
+	json1 := `{"hobbies":["reading","swimming"]}`
+	json2 := `{"hobbies":["cycling","reading","swimming"]}`
+
+	diff, err := NewJSONDiffService().CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("比较JSON时出错: %v", err)
+		return
+	}
+	if _, exists := diff.Changed["hobbies[0]"]; !exists {
+		t.Errorf("预期默认模式下'hobbies[0]'发生变更，但实际没有")
+	}
+	if !containsPath(diff.Added, "hobbies[2]") {
+		t.Errorf("预期默认模式下新增'hobbies[2]'，但实际为: %v", diff.Added)
+	}
+
+	explicit, err := NewJSONDiffService(WithArrayDiffMode(ArrayDiffPositional)).CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("比较JSON时出错: %v", err)
+		return
+	}
+	if len(explicit.Changed) != len(diff.Changed) || len(explicit.Added) != len(diff.Added) {
+		t.Errorf("显式指定按下标比较应与默认结果相同")
+	}
+}
+
+// TestLCSPairs 测试最长公共子序列的匹配下标
+func TestLCSPairs(t *testing.T) {
+	a1 := []interface{}{"a", "b", "c", "d", "e"}
+	a2 := []interface{}{"a", "x", "c", "e", "y"}
+
+	pairs := lcsPairs(a1, a2, nil)
+	expected := [][2]int{{0, 0}, {2, 2}, {4, 3}}
+	if len(pairs) != len(expected) {
+		t.Fatalf("预期匹配%v，但实际为%v", expected, pairs)
+	}
+	for i := range expected {
+		if pairs[i] != expected[i] {
+			t.Errorf("预期匹配%v，但实际为%v", expected, pairs)
+		}
+	}
+}
+
+// TestCompareJSON_KeyedArrays 测试按标识字段配对数组元素
+func TestCompareJSON_KeyedArrays(t *testing.T) {
+	service := NewJSONDiffService(
+		WithArrayKey("employees[*]", "id"),
+		WithArrayKey("offices[*]", "location"),
+	)
+	json1 := `{
+		"employees": [
+			{"id": 1, "name": "Alice", "skills": ["Go"]},
+			{"id": 2, "name": "Bob"}
+		],
+		"offices": [
+			{"location": "New York", "zip": "10001"},
+			{"location": "London", "zip": "W1D 1BS"}
+		]
+	}`
+	json2 := `{
+		"employees": [
+			{"id": 3, "name": "Charlie"},
+			{"id": 1, "name": "Alice Chen", "skills": ["Go"]}
+		],
+		"offices": [
+			{"location": "Berlin", "zip": "10117"},
+			{"location": "New York", "zip": "10001", "country": "USA"}
+		]
+	}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("按标识字段比较JSON时出错: %v", err)
+		return
+	}
+
+	// 配对元素的变更使用第二个数组中的下标
+	if _, exists := diff.Changed["employees[1].name"]; !exists || len(diff.Changed) != 1 {
+		t.Errorf("预期只有'employees[1].name'发生变更，但实际为: %v", diff.Changed)
+	}
+
+	expectedAdded := []string{"employees[0]", "offices[0]", "offices[1].country"}
+	for _, path := range expectedAdded {
+		if !containsPath(diff.Added, path) {
+			t.Errorf("预期'%s'被新增，但实际为: %v", path, diff.Added)
+		}
+	}
+	if len(diff.Added) != len(expectedAdded) {
+		t.Errorf("预期有%d个新增，但实际为: %v", len(expectedAdded), diff.Added)
+	}
+
+	expectedRemoved := []string{"employees[1]", "offices[1]"}
+	for _, path := range expectedRemoved {
+		if !containsPath(diff.Removed, path) {
+			t.Errorf("预期'%s'被移除，但实际为: %v", path, diff.Removed)
+		}
+	}
+	if len(diff.Removed) != len(expectedRemoved) {
+		t.Errorf("预期有%d个移除，但实际为: %v", len(expectedRemoved), diff.Removed)
+	}
+}
+
+// TestCompareJSON_KeyedArraysCompositeKey 测试使用多个字段组成的组合键配对元素
+func TestCompareJSON_KeyedArraysCompositeKey(t *testing.T) {
+	service := NewJSONDiffService(WithArrayKey("departments[*].members", "team", "id"))
+	json1 := `{"departments":[{"members":[{"team":"a","id":1,"role":"dev"},{"team":"b","id":1,"role":"qa"}]}]}`
+	json2 := `{"departments":[{"members":[{"team":"b","id":1,"role":"lead"},{"team":"a","id":1,"role":"dev"}]}]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("使用组合键比较JSON时出错: %v", err)
+		return
+	}
+
+	if _, exists := diff.Changed["departments[0].members[0].role"]; !exists || len(diff.Changed) != 1 {
+		t.Errorf("预期只有'departments[0].members[0].role'发生变更，但实际为: %v", diff.Changed)
+	}
+	if len(diff.Added) > 0 || len(diff.Removed) > 0 {
+		t.Errorf("预期没有新增和移除，但实际新增: %v，移除: %v", diff.Added, diff.Removed)
+	}
+}
+
+// TestCompareJSON_KeyedArraysMissingKey 测试缺少标识字段的元素不影响其余元素按标识配对
+func TestCompareJSON_KeyedArraysMissingKey(t *testing.T) {
+	service := NewJSONDiffService(WithArrayKey("items[*]", "id"))
+	json1 := `{"items":[{"id":1,"v":"a"},{"v":"b"},{"id":2,"v":"x"},"same"]}`
+	json2 := `{"items":["same",{"id":2,"v":"y"},{"id":1,"v":"a"},{"v":"c"}]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("比较JSON时出错: %v", err)
+		return
+	}
+
+	if _, exists := diff.Changed["items[1].v"]; !exists || len(diff.Changed) != 1 {
+		t.Errorf("预期按标识配对后只有'items[1].v'发生变更，但实际为: %v", diff.Changed)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "items[1]" {
+		t.Errorf("预期缺少标识的'items[1]'整体被移除，但实际为: %v", diff.Removed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "items[3]" {
+		t.Errorf("预期缺少标识的'items[3]'整体被新增，但实际为: %v", diff.Added)
+	}
+}
+
+// TestCompareJSON_UnorderedArrays 测试按多重集合比较的数组忽略元素顺序
+func TestCompareJSON_UnorderedArrays(t *testing.T) {
+	service := NewJSONDiffService(WithUnorderedArrays("tags", "employees[*].skills"))
+	json1 := `{"tags":["Go","Python"],"employees":[{"skills":["Go","SQL","Docker"]}],"hobbies":["a","b"]}`
+	json2 := `{"tags":["Python","Go"],"employees":[{"skills":["Docker","Go","Rust"]}],"hobbies":["b","a"]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("按多重集合比较JSON时出错: %v", err)
+		return
+	}
+
+	for path := range diff.Changed {
+		if path != "hobbies[0]" && path != "hobbies[1]" {
+			t.Errorf("预期只有未声明为无序的'hobbies'发生变更，但实际有: %s", path)
+		}
+	}
+	if len(diff.Changed) != 2 {
+		t.Errorf("预期'hobbies'按下标比较产生2个变更，但实际为: %v", diff.Changed)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "employees[0].skills[1]" {
+		t.Errorf("预期只移除'employees[0].skills[1]'，但实际为: %v", diff.Removed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "employees[0].skills[2]" {
+		t.Errorf("预期只新增'employees[0].skills[2]'，但实际为: %v", diff.Added)
+	}
+}
+
+// TestCompareJSON_UnorderedArraysDuplicates 测试多重集合比较时正确计算重复元素
+func TestCompareJSON_UnorderedArraysDuplicates(t *testing.T) {
+	service := NewJSONDiffService(WithUnorderedArrays("values"))
+	json1 := `{"values":["a","b","a","a",{"k":1}]}`
+	json2 := `{"values":[{"k":1},"a","b","b","a"]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("按多重集合比较JSON时出错: %v", err)
+		return
+	}
+
+	// 第一个数组中有三个"a"，第二个中只有两个，多出的最后一个被移除；"b"多出一个被新增
+	if len(diff.Removed) != 1 || diff.Removed[0] != "values[3]" {
+		t.Errorf("预期只移除'values[3]'，但实际为: %v", diff.Removed)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "values[3]" {
+		t.Errorf("预期只新增'values[3]'，但实际为: %v", diff.Added)
+	}
+	if len(diff.Changed) > 0 {
+		t.Errorf("预期没有变更字段，但实际有: %v", diff.Changed)
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ChangeKind 表示一条差异记录的类型
+type ChangeKind string
+
+const (
+	ChangeAdded         ChangeKind = "added"          // 第二个JSON中新增的值
+	ChangeRemoved       ChangeKind = "removed"        // 第二个JSON中被移除的值
+	ChangeValueChanged  ChangeKind = "value-changed"  // 类型相同但值不同
+	ChangeTypeChanged   ChangeKind = "type-changed"   // JSON类型发生变化（包括 null 与非 null 之间的变化）
+	ChangeLengthChanged ChangeKind = "length-changed" // 按下标比较的数组长度发生变化
+	ChangeMoved         ChangeKind = "moved"          // 值从 From 移动到 Path
+)
+
+// JSONType 表示JSON值的类型
+type JSONType string
+
+const (
+	JSONNull    JSONType = "null"
+	JSONBoolean JSONType = "boolean"
+	JSONNumber  JSONType = "number"
+	JSONString  JSONType = "string"
+	JSONArray   JSONType = "array"
+	JSONObject  JSONType = "object"
+)
+
+// Change 表示一条结构化的差异记录，旧值和新值保留解析后的原始类型
+type Change struct {
+	Kind     ChangeKind  `json:"kind"`               // 差异类型
+	Path     string      `json:"path"`               // 差异所在路径，移动时为移动后的路径
+	From     string      `json:"from,omitempty"`     // 移动前的路径，仅在移动时设置
+	OldValue interface{} `json:"oldValue,omitempty"` // 第一个JSON中的值，长度变更时为原数组长度
+	NewValue interface{} `json:"newValue,omitempty"` // 第二个JSON中的值，长度变更时为新数组长度
+	OldType  JSONType    `json:"oldType,omitempty"`  // 旧值的JSON类型，新增时为空
+	NewType  JSONType    `json:"newType,omitempty"`  // 新值的JSON类型，移除时为空
+}
+
+// OldRaw 返回旧值的JSON编码，没有旧值时返回 nil
+func (c Change) OldRaw() json.RawMessage {
+	if c.OldType == "" {
+		return nil
+	}
+	return rawValue(c.OldValue)
+}
+
+// NewRaw 返回新值的JSON编码，没有新值时返回 nil
+func (c Change) NewRaw() json.RawMessage {
+	if c.NewType == "" {
+		return nil
+	}
+	return rawValue(c.NewValue)
+}
+
+// rawValue 把解析后的值重新编码为JSON
+func rawValue(v interface{}) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// jsonTypeOf 返回解析后的值对应的JSON类型
+func jsonTypeOf(v interface{}) JSONType {
+	switch v.(type) {
+	case nil:
+		return JSONNull
+	case bool:
+		return JSONBoolean
+	case float64, json.Number:
+		return JSONNumber
+	case string:
+		return JSONString
+	case []interface{}:
+		return JSONArray
+	case map[string]interface{}:
+		return JSONObject
+	}
+	return ""
+}
+
+// legacyKinds 把JSON类型映射为旧版变更描述中使用的类型名称
+var legacyKinds = map[JSONType]string{
+	JSONBoolean: "bool",
+	JSONNumber:  "float64",
+	JSONString:  "string",
+	JSONArray:   "slice",
+	JSONObject:  "map",
+}
+
+// describe 生成旧版 Changed 字段使用的变更描述，按 DetailPaths 去掉了值的差异只描述差异类型
+func (c Change) describe() string {
+	if c.valuesStripped() {
+		switch c.Kind {
+		case ChangeLengthChanged:
+			return "数组长度变更"
+		case ChangeTypeChanged:
+			return fmt.Sprintf("类型变更: %s -> %s", c.OldType, c.NewType)
+		}
+		return "值变更"
+	}
+	switch {
+	case c.Kind == ChangeLengthChanged:
+		return fmt.Sprintf("数组长度变更: %v -> %v", c.OldValue, c.NewValue)
+	case c.OldType == JSONNull:
+		return fmt.Sprintf("值变更: null -> %v", c.NewValue)
+	case c.NewType == JSONNull:
+		return fmt.Sprintf("值变更: %v -> null", c.OldValue)
+	case c.Kind == ChangeTypeChanged:
+		return fmt.Sprintf("类型变更: %v -> %v", legacyKinds[c.OldType], legacyKinds[c.NewType])
+	}
+	return fmt.Sprintf("值变更: %v -> %v", c.OldValue, c.NewValue)
+}
+
+// valuesStripped 判断差异是否按 DetailPaths 去掉了旧值和新值：类型不是 null 的一侧没有值
+func (c Change) valuesStripped() bool {
+	return (c.OldType != "" && c.OldType != JSONNull && c.OldValue == nil) ||
+		(c.NewType != "" && c.NewType != JSONNull && c.NewValue == nil)
+}
+
+// record 把一条差异交给 sink；
+// 白名单模式下不在包含范围内的路径（包括只遍历的祖先路径）不记录，差异数量达到上限后也不再记录
+func (d *jsonDiffer) record(c Change) {
+	if d.included(c.Path) != includeAll || (c.Kind == ChangeMoved && d.included(c.From) != includeAll) {
+		return
+	}
+	if d.full() {
+		return
+	}
+	d.recorded++
+	if err := d.sink.Record(c); err != nil {
+		d.err = err
+	}
+}
+
+// appendChange 追加一条差异记录并维护旧版字段
+func (r *JSONDiffResult) appendChange(c Change) {
+	r.Changes = append(r.Changes, c)
+	switch c.Kind {
+	case ChangeAdded:
+		r.Added = append(r.Added, c.Path)
+	case ChangeRemoved:
+		r.Removed = append(r.Removed, c.Path)
+	case ChangeMoved:
+		r.Moved = append(r.Moved, MovedPath{From: c.From, To: c.Path})
+	default:
+		r.Changed[c.Path] = c.describe()
+	}
+}
+
+// recordValueChange 记录两个值之间的变更，类型不同时记录为类型变更
+func (d *jsonDiffer) recordValueChange(path string, v1, v2 interface{}) {
+	c := Change{
+		Kind:     ChangeValueChanged,
+		Path:     path,
+		OldValue: v1,
+		NewValue: v2,
+		OldType:  jsonTypeOf(v1),
+		NewType:  jsonTypeOf(v2),
+	}
+	if c.OldType != c.NewType {
+		c.Kind = ChangeTypeChanged
+	}
+	d.record(c)
+}
+
+// dropChanges 从结果中删除指定类型和路径的差异记录，同时维护旧版字段
+func (r *JSONDiffResult) dropChanges(kind ChangeKind, paths map[string]bool) {
+	kept := r.Changes[:0]
+	for _, c := range r.Changes {
+		if c.Kind != kind || !paths[c.Path] {
+			kept = append(kept, c)
+		}
+	}
+	r.Changes = kept
+
+	switch kind {
+	case ChangeAdded:
+		r.Added = filterPaths(r.Added, paths)
+	case ChangeRemoved:
+		r.Removed = filterPaths(r.Removed, paths)
+	}
+}
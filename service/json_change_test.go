@@ -0,0 +1,140 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// findChange 按路径查找差异记录
+func findChange(changes []Change, path string) (Change, bool) {
+	for _, c := range changes {
+		if c.Path == path {
+			return c, true
+		}
+	}
+	return Change{}, false
+}
+
+// TestCompareJSON_StructuredChanges 测试结构化差异记录中的类型、旧值和新值
+func TestCompareJSON_StructuredChanges(t *testing.T) {
+	service := NewJSONDiffService()
+	json1 := `{"age":30,"name":"Alice","tags":["a"],"zip":"10001","note":null,"address":{"city":"NY"}}`
+	json2 := `{"age":31,"name":42,"tags":["a","b"],"note":"hi","email":"a@b.c","address":{"city":"NY"}}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("比较JSON时出错: %v", err)
+		return
+	}
+
+	testCases := []struct {
+		path     string
+		kind     ChangeKind
+		oldValue interface{}
+		newValue interface{}
+		oldType  JSONType
+		newType  JSONType
+	}{
+		{"age", ChangeValueChanged, 30.0, 31.0, JSONNumber, JSONNumber},
+		{"name", ChangeTypeChanged, "Alice", 42.0, JSONString, JSONNumber},
+		{"tags", ChangeLengthChanged, 1, 2, JSONArray, JSONArray},
+		{"tags[1]", ChangeAdded, nil, "b", "", JSONString},
+		{"zip", ChangeRemoved, "10001", nil, JSONString, ""},
+		{"note", ChangeTypeChanged, nil, "hi", JSONNull, JSONString},
+		{"email", ChangeAdded, nil, "a@b.c", "", JSONString},
+	}
+
+	if len(diff.Changes) != len(testCases) {
+		t.Errorf("预期有%d条差异记录，但实际为: %v", len(testCases), diff.Changes)
+	}
+	for _, tc := range testCases {
+		c, found := findChange(diff.Changes, tc.path)
+		if !found {
+			t.Errorf("预期'%s'有差异记录，但实际没有", tc.path)
+			continue
+		}
+		if c.Kind != tc.kind || c.OldType != tc.oldType || c.NewType != tc.newType {
+			t.Errorf("'%s'的差异记录为%+v，预期类型%s（%s -> %s）", tc.path, c, tc.kind, tc.oldType, tc.newType)
+		}
+		if !reflect.DeepEqual(c.OldValue, tc.oldValue) || !reflect.DeepEqual(c.NewValue, tc.newValue) {
+			t.Errorf("'%s'的值为%v -> %v，预期%v -> %v", tc.path, c.OldValue, c.NewValue, tc.oldValue, tc.newValue)
+		}
+	}
+}
+
+// TestCompareJSON_LegacyFieldsFromChanges 测试旧版字段与结构化记录保持一致
+func TestCompareJSON_LegacyFieldsFromChanges(t *testing.T) {
+	service := NewJSONDiffService()
+	json1 := `{"age":30,"name":"Alice","note":null,"tags":["a"]}`
+	json2 := `{"age":31,"name":42,"note":"hi","tags":["a","b"]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("比较JSON时出错: %v", err)
+		return
+	}
+
+	expected := map[string]string{
+		"age":  "值变更: 30 -> 31",
+		"name": "类型变更: string -> float64",
+		"note": "值变更: null -> hi",
+		"tags": "数组长度变更: 1 -> 2",
+	}
+	for path, desc := range expected {
+		if diff.Changed[path] != desc {
+			t.Errorf("'%s'的变更描述为%q，预期%q", path, diff.Changed[path], desc)
+		}
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "tags[1]" {
+		t.Errorf("预期只新增'tags[1]'，但实际为: %v", diff.Added)
+	}
+}
+
+// TestCompareJSON_MovedChangeRecord 测试移动记录包含移动前后的路径和值
+func TestCompareJSON_MovedChangeRecord(t *testing.T) {
+	service := NewJSONDiffService(WithMoveDetection())
+	json1 := `{"a":{"x":{"k":1}}}`
+	json2 := `{"b":{"x":{"k":1}}}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("比较JSON时出错: %v", err)
+		return
+	}
+
+	c, found := findChange(diff.Changes, "b")
+	if !found || c.Kind != ChangeMoved || c.From != "a" {
+		t.Errorf("预期有'a -> b'的移动记录，但实际为: %v", diff.Changes)
+	}
+	if len(diff.Changes) != 1 {
+		t.Errorf("预期只有一条移动记录，但实际为: %v", diff.Changes)
+	}
+	if string(c.NewRaw()) != `{"x":{"k":1}}` {
+		t.Errorf("移动记录的新值编码为%s", c.NewRaw())
+	}
+}
+
+// TestChange_RawValues 测试差异记录的JSON编码
+func TestChange_RawValues(t *testing.T) {
+	added := Change{Kind: ChangeAdded, Path: "a", NewValue: map[string]interface{}{"k": []interface{}{1.0, nil}}, NewType: JSONObject}
+	if added.OldRaw() != nil {
+		t.Errorf("新增记录不应有旧值编码，但实际为%s", added.OldRaw())
+	}
+	if string(added.NewRaw()) != `{"k":[1,null]}` {
+		t.Errorf("新增记录的新值编码为%s", added.NewRaw())
+	}
+
+	toNull := Change{Kind: ChangeTypeChanged, Path: "a", OldValue: "x", NewValue: nil, OldType: JSONString, NewType: JSONNull}
+	if string(toNull.NewRaw()) != "null" {
+		t.Errorf("变为null的记录新值编码应为null，但实际为%s", toNull.NewRaw())
+	}
+
+	data, err := json.Marshal(toNull)
+	if err != nil {
+		t.Fatalf("序列化差异记录时出错: %v", err)
+	}
+	if string(data) != `{"kind":"type-changed","path":"a","oldValue":"x","oldType":"string","newType":"null"}` {
+		t.Errorf("差异记录序列化结果为%s", data)
+	}
+}
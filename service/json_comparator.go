@@ -0,0 +1,184 @@
+package service
+
+import (
+	"net/url"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Comparator 自定义两个JSON基本类型值（字符串、数字、布尔值）是否相等
+type Comparator interface {
+	// Equal 判断两个值是否相等，ok 为 false 表示比较器不适用于这两个值，继续使用默认比较
+	Equal(a, b interface{}) (equal bool, ok bool)
+}
+
+// ComparatorFunc 把函数适配为 Comparator
+type ComparatorFunc func(a, b interface{}) (equal bool, ok bool)
+
+// Equal 调用函数本身
+func (f ComparatorFunc) Equal(a, b interface{}) (bool, bool) {
+	return f(a, b)
+}
+
+// comparatorRule 表示一条按路径模式匹配的比较器规则
+type comparatorRule struct {
+	pattern    string
+	comparator Comparator
+}
+
+// WithComparator 为匹配 pattern 的值注册比较器，pattern 使用与忽略路径相同的语法；
+// 按路径注册的比较器优先于按类型注册的比较器，多条规则匹配同一路径时使用最先注册的规则；
+// 数组元素的配对（LCS、忽略顺序、按标识字段和移动检测）同样按比较器判断元素是否相等
+func WithComparator(pattern string, comparator Comparator) Option {
+	return func(c *diffConfig) {
+		c.comparators = append(c.comparators, comparatorRule{pattern: pattern, comparator: comparator})
+	}
+}
+
+// WithTypeComparator 为指定JSON类型的值注册比较器，只对字符串、数字和布尔值生效
+func WithTypeComparator(t JSONType, comparator Comparator) Option {
+	return func(c *diffConfig) {
+		if c.typeComparators == nil {
+			c.typeComparators = make(map[JSONType]Comparator)
+		}
+		c.typeComparators[t] = comparator
+	}
+}
+
+// customEqual 使用路径或类型对应的比较器比较两个同类型的基本类型值，没有适用的比较器时 ok 为 false
+func (c *diffConfig) customEqual(path string, v1, v2 interface{}) (equal bool, ok bool) {
+	if i := c.compiled().comparators.first(path); i >= 0 {
+		if equal, ok := c.comparators[i].comparator.Equal(v1, v2); ok {
+			return equal, true
+		}
+	}
+	if comparator, exists := c.typeComparators[jsonTypeOf(v1)]; exists {
+		return comparator.Equal(v1, v2)
+	}
+	return false, false
+}
+
+// primitiveEqual 比较两个同类型的基本类型值：有适用的比较器时以比较器的结果为准，
+// 否则使用 reflect.DeepEqual，数字在容差范围内时也视为相等
+func (c *diffConfig) primitiveEqual(path string, v1, v2 interface{}) bool {
+	if equal, ok := c.customEqual(path, v1, v2); ok {
+		return equal
+	}
+	return reflect.DeepEqual(v1, v2) || c.withinTolerance(path, v1, v2)
+}
+
+// stringComparator 构造比较规范形式的字符串比较器，任一值不是字符串或无法规范化时不适用
+func stringComparator(normalize func(string) (string, bool)) Comparator {
+	return ComparatorFunc(func(a, b interface{}) (bool, bool) {
+		s1, ok1 := a.(string)
+		s2, ok2 := b.(string)
+		if !ok1 || !ok2 {
+			return false, false
+		}
+		n1, ok1 := normalize(s1)
+		n2, ok2 := normalize(s2)
+		if !ok1 || !ok2 {
+			return false, false
+		}
+		return n1 == n2, true
+	})
+}
+
+// CaseInsensitiveComparator 忽略大小写比较字符串
+var CaseInsensitiveComparator Comparator = ComparatorFunc(func(a, b interface{}) (bool, bool) {
+	s1, ok1 := a.(string)
+	s2, ok2 := b.(string)
+	if !ok1 || !ok2 {
+		return false, false
+	}
+	return strings.EqualFold(s1, s2), true
+})
+
+// TrimSpaceComparator 忽略首尾空白比较字符串
+var TrimSpaceComparator = stringComparator(func(s string) (string, bool) {
+	return strings.TrimSpace(s), true
+})
+
+// TimeComparator 把字符串按 layouts 解析为时间后比较时刻，时区不同但时刻相同的时间相等；
+// 不指定 layouts 时使用 RFC 3339
+func TimeComparator(layouts ...string) Comparator {
+	if len(layouts) == 0 {
+		layouts = []string{time.RFC3339Nano}
+	}
+	return stringComparator(func(s string) (string, bool) {
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC().Format(time.RFC3339Nano), true
+			}
+		}
+		return "", false
+	})
+}
+
+// semverPattern 匹配语义化版本号，允许 v 前缀
+var semverPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?(\+[0-9A-Za-z-]+(\.[0-9A-Za-z-]+)*)?$`)
+
+// SemverComparator 按语义化版本的优先级比较版本号，忽略 v 前缀和构建元数据，例如 v1.2.3 与 1.2.3+build.5 相等
+var SemverComparator = stringComparator(func(s string) (string, bool) {
+	m := semverPattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	return m[1] + "." + m[2] + "." + m[3] + m[4], true
+})
+
+// uuidPattern 匹配去掉连字符后的32位十六进制 UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// UUIDComparator 按规范形式比较 UUID，忽略大小写、连字符、花括号和 urn:uuid: 前缀
+var UUIDComparator = stringComparator(func(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "urn:uuid:")
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+	s = strings.ReplaceAll(s, "-", "")
+	return s, uuidPattern.MatchString(s)
+})
+
+// defaultPorts 各协议的默认端口
+var defaultPorts = map[string]string{"http": "80", "https": "443", "ws": "80", "wss": "443", "ftp": "21"}
+
+// URLComparator 按 RFC 3986 的规范化规则比较 URL：协议和主机名不区分大小写，
+// 省略默认端口，空路径视为 /，消除 . 和 .. 路径段，百分号编码统一形式
+var URLComparator = stringComparator(func(s string) (string, bool) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	p := u.Path
+	if p == "" && u.Host != "" {
+		p = "/"
+	}
+	if p != "" {
+		cleaned := path.Clean(p)
+		if strings.HasSuffix(p, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		p = cleaned
+	}
+	// 重新编码路径，统一百分号编码的形式
+	u.Path, u.RawPath = p, ""
+	return u.String(), true
+})
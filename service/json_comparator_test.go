@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestCompareJSON_PathComparators 测试按路径注册的比较器
+func TestCompareJSON_PathComparators(t *testing.T) {
+	service := NewJSONDiffService(
+		WithComparator("users[*].email", CaseInsensitiveComparator),
+		WithComparator("users[*].createdAt", TimeComparator()),
+		WithComparator("version", SemverComparator),
+	)
+	json1 := `{"users":[{"email":"Alice@Example.com","createdAt":"2024-01-02T08:00:00Z","name":"Alice"}],"version":"v1.2.3","build":"1.2.3"}`
+	json2 := `{"users":[{"email":"alice@example.COM","createdAt":"2024-01-02T16:00:00+08:00","name":"alice"}],"version":"1.2.3+build.7","build":"v1.2.3"}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	for _, path := range []string{"users[0].email", "users[0].createdAt", "version"} {
+		if _, exists := diff.Changed[path]; exists {
+			t.Errorf("%s按比较器相等，不应被检测到变更", path)
+		}
+	}
+	for _, path := range []string{"users[0].name", "build"} {
+		if _, exists := diff.Changed[path]; !exists {
+			t.Errorf("%s没有注册比较器，应该被检测到变更", path)
+		}
+	}
+}
+
+// TestCompareJSON_TypeComparators 测试按类型注册的比较器以及路径比较器的优先级
+func TestCompareJSON_TypeComparators(t *testing.T) {
+	service := NewJSONDiffService(
+		WithTypeComparator(JSONString, TrimSpaceComparator),
+		WithComparator("id", UUIDComparator),
+		WithTypeComparator(JSONNumber, ComparatorFunc(func(a, b interface{}) (bool, bool) {
+			return true, true
+		})),
+	)
+	json1 := `{"name":" Alice ","id":"{6BA7B810-9DAD-11D1-80B4-00C04FD430C8}","code":" x","count":1,"note":"not a uuid"}`
+	json2 := `{"name":"Alice","id":"urn:uuid:6ba7b8109dad11d180b400c04fd430c8","code":"y","count":2,"note":"not a uuid "}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if len(diff.Changed) != 1 {
+		t.Errorf("预期只有code变更，但实际为%v", diff.Changed)
+	}
+	if _, exists := diff.Changed["code"]; !exists {
+		t.Errorf("code应该被检测到变更")
+	}
+}
+
+// TestBuiltinComparators 测试内置比较器
+func TestBuiltinComparators(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		comparator Comparator
+		a, b       interface{}
+		equal      bool
+		ok         bool
+	}{
+		{"忽略大小写", CaseInsensitiveComparator, "ABC", "abc", true, true},
+		{"忽略大小写不适用于数字", CaseInsensitiveComparator, 1.0, 1.0, false, false},
+		{"忽略首尾空白", TrimSpaceComparator, "\ta b\n", "a b", true, true},
+		{"空白不在首尾", TrimSpaceComparator, "a  b", "a b", false, true},
+		{"不同时区的同一时刻", TimeComparator(), "2024-05-01T00:00:00Z", "2024-04-30T20:00:00-04:00", true, true},
+		{"不同的时刻", TimeComparator(), "2024-05-01T00:00:00Z", "2024-05-01T00:00:01Z", false, true},
+		{"自定义时间格式", TimeComparator("2006-01-02"), "2024-05-01", "2024-05-01", true, true},
+		{"无法解析的时间", TimeComparator(), "yesterday", "yesterday", false, false},
+		{"版本号忽略构建元数据", SemverComparator, "1.0.0-rc.1+a", "v1.0.0-rc.1+b", true, true},
+		{"版本号的预发布标识不同", SemverComparator, "1.0.0-rc.1", "1.0.0", false, true},
+		{"无效的版本号", SemverComparator, "1.0", "1.0", false, false},
+		{"UUID大小写和连字符", UUIDComparator, "6BA7B810-9DAD-11D1-80B4-00C04FD430C8", "6ba7b8109dad11d180b400c04fd430c8", true, true},
+		{"不同的UUID", UUIDComparator, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "6ba7b811-9dad-11d1-80b4-00c04fd430c8", false, true},
+		{"URL规范化", URLComparator, "HTTP://Example.COM:80/a/./b/../c", "http://example.com/a/c", true, true},
+		{"URL空路径", URLComparator, "https://example.com", "https://example.com:443/", true, true},
+		{"URL百分号编码", URLComparator, "http://example.com/%7Euser", "http://example.com/~user", true, true},
+		{"URL查询参数不同", URLComparator, "http://example.com/?a=1", "http://example.com/?a=2", false, true},
+		{"相对URL不适用", URLComparator, "/a", "/a", false, false},
+		{"json.Number不是字符串", CaseInsensitiveComparator, json.Number("1"), "1", false, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			equal, ok := tc.comparator.Equal(tc.a, tc.b)
+			if equal != tc.equal || ok != tc.ok {
+				t.Errorf("Equal(%v, %v) = (%v, %v)，预期(%v, %v)", tc.a, tc.b, equal, ok, tc.equal, tc.ok)
+			}
+		})
+	}
+}
+
+// TestCompareJSON_ComparatorsPairArrayElements 测试忽略顺序的数组、缺少标识字段的元素和移动检测在配对元素时同样使用比较器和容差
+func TestCompareJSON_ComparatorsPairArrayElements(t *testing.T) {
+	tests := []struct {
+		desc         string
+		opts         []Option
+		json1, json2 string
+	}{
+		{"忽略顺序", []Option{WithUnorderedArrays("tags"), WithTypeComparator(JSONString, CaseInsensitiveComparator)},
+			`{"tags":["Go","Py"]}`, `{"tags":["py","go"]}`},
+		{"缺少标识字段", []Option{WithArrayKey("items[*]", "id"), WithTolerance("items[*].v", Tolerance{Absolute: 0.1})},
+			`{"items":[{"v":1},{"id":1}]}`, `{"items":[{"id":1},{"v":1.05}]}`},
+	}
+	for _, tt := range tests {
+		diff, err := NewJSONDiffService(tt.opts...).CompareJSON(tt.json1, tt.json2)
+		if err != nil {
+			t.Fatalf("%s: 比较JSON时出错: %v", tt.desc, err)
+		}
+		if len(diff.Changes) != 0 {
+			t.Errorf("%s: 按比较器相等的元素应该配对，实际差异为%+v", tt.desc, diff.Changes)
+		}
+	}
+
+	// 移动检测按比较器识别被移动的元素
+	service := NewJSONDiffService(WithMoveDetection(), WithComparator("list[*]", CaseInsensitiveComparator))
+	diff, err := service.CompareJSON(`{"list":["a","b","c"]}`, `{"list":["C","a","b"]}`)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if len(diff.Changes) != 1 || diff.Changes[0].Kind != ChangeMoved || diff.Changes[0].From != "list[2]" {
+		t.Errorf("预期只有'list[2] -> list[0]'的移动，实际为%+v", diff.Changes)
+	}
+}
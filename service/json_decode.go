@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// NumberMode 表示解析和比较数字的方式
+type NumberMode int
+
+const (
+	// NumberFloat64 数字解析为 float64（默认），超出 float64 精度的数字可能被视为相等
+	NumberFloat64 NumberMode = iota
+	// NumberExact 数字按精确的十进制值比较，1 与 1.0、1e2 与 100 相等；
+	// 差异结果和补丁中的数字使用规范形式（去掉多余的零，必要时使用指数形式），类型为 json.Number
+	NumberExact
+	// NumberLiteral 数字按原始写法比较，1 与 1.0 不同；差异结果和补丁中的数字保留原始写法，类型为 json.Number
+	NumberLiteral
+)
+
+// WithNumberMode 设置解析和比较数字的方式，对比较、补丁和合并的所有方法生效
+func WithNumberMode(mode NumberMode) Option {
+	return func(c *diffConfig) {
+		c.numbers = mode
+	}
+}
+
+// parseJSONPair 解析待比较的两个JSON字符串，order 不为 nil 时记录对象成员的顺序
+func (c *diffConfig) parseJSONPair(json1, json2 string, order keyOrder) (interface{}, interface{}, error) {
+	// 解析第一个JSON字符串
+	obj1, err := c.parseDocument(json1, order)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析第一个JSON失败: %v", err)
+	}
+
+	// 解析第二个JSON字符串
+	obj2, err := c.parseDocument(json2, order)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析第二个JSON失败: %v", err)
+	}
+	return obj1, obj2, nil
+}
+
+// parseDocument 按配置的数字方式解析JSON文档，order 不为 nil 时同时记录对象成员的顺序
+func (c *diffConfig) parseDocument(doc string, order keyOrder) (interface{}, error) {
+	return c.parseBytes([]byte(doc), order)
+}
+
+// parseBytes 按配置的数字方式解析字节形式的JSON文档
+func (c *diffConfig) parseBytes(doc []byte, order keyOrder) (interface{}, error) {
+	v, err := decodeDocument(doc, order, c.numbers != NumberFloat64)
+	if err != nil {
+		return nil, err
+	}
+	return c.canonical(v), nil
+}
+
+// parseReader 按配置的数字方式从 r 中读取并解析一个JSON文档，直到 r 结束
+func (c *diffConfig) parseReader(r io.Reader, order keyOrder) (interface{}, error) {
+	v, err := decodeStream(r, order, c.numbers != NumberFloat64)
+	if err != nil {
+		return nil, err
+	}
+	return c.canonical(v), nil
+}
+
+// canonical 在 NumberExact 方式下把解析结果中的数字替换为规范形式
+func (c *diffConfig) canonical(v interface{}) interface{} {
+	if c.numbers == NumberExact {
+		return canonicalNumbers(v)
+	}
+	return v
+}
+
+// decodeDocument 解析JSON文档，useNumber 为 true 时数字解析为 json.Number
+func decodeDocument(doc []byte, order keyOrder, useNumber bool) (interface{}, error) {
+	var v interface{}
+	if order == nil && !useNumber {
+		err := json.Unmarshal(doc, &v)
+		return v, err
+	}
+
+	v, err := decodeStream(bytes.NewReader(doc), order, useNumber)
+	if err == nil {
+		return v, nil
+	}
+	// 使用 json.Unmarshal 的错误信息，与默认的解析方式保持一致
+	var ignored interface{}
+	if uerr := json.Unmarshal(doc, &ignored); uerr != nil {
+		return nil, uerr
+	}
+	return nil, err
+}
+
+// decodeStream 从 r 中解析一个JSON值，值之后只允许有空白；useNumber 为 true 时数字解析为 json.Number
+func decodeStream(r io.Reader, order keyOrder, useNumber bool) (interface{}, error) {
+	dec := json.NewDecoder(r)
+	if useNumber {
+		dec.UseNumber()
+	}
+	var v interface{}
+	var err error
+	if order == nil {
+		err = dec.Decode(&v)
+	} else {
+		v, err = decodeOrdered(dec, order)
+	}
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			return v, nil
+		}
+		if err == nil {
+			err = errors.New("值之后有多余的内容")
+		}
+	}
+	return nil, fmt.Errorf("无效的JSON: %w", err)
+}
+
+// decodeOrdered 从解码器中读取一个值，并记录其中每个对象的成员顺序
+func decodeOrdered(dec *json.Decoder, order keyOrder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrderedFrom(dec, tok, order)
+}
+
+// decodeOrderedFrom 从已经读取的第一个标记开始读取一个值，并记录其中每个对象的成员顺序
+func decodeOrderedFrom(dec *json.Decoder, tok json.Token, order keyOrder) (interface{}, error) {
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		m := make(map[string]interface{})
+		var keys []string
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := tok.(string)
+			v, err := decodeOrdered(dec, order)
+			if err != nil {
+				return nil, err
+			}
+			// 重复的键保留最后一个值和第一次出现的位置
+			if _, exists := m[key]; !exists {
+				keys = append(keys, key)
+			}
+			m[key] = v
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		order[reflect.ValueOf(m).Pointer()] = keys
+		return m, nil
+	case '[':
+		a := []interface{}{}
+		for dec.More() {
+			v, err := decodeOrdered(dec, order)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+	return nil, fmt.Errorf("意外的分隔符 %v", delim)
+}
+
+// canonicalNumbers 把值中的所有 json.Number 替换为规范形式，对象和数组被原地修改
+func canonicalNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		return canonicalNumber(t)
+	case []interface{}:
+		for i, e := range t {
+			t[i] = canonicalNumbers(e)
+		}
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = canonicalNumbers(e)
+		}
+	}
+	return v
+}
+
+// canonicalNumber 返回数字的规范形式，数值相等的数字得到相同的字符串。
+// 只做字符串处理，不会因为很大的指数分配大量内存
+func canonicalNumber(n json.Number) json.Number {
+	s := string(n)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	// 拆分为有效数字和指数，数值为 digits × 10^exp
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return n
+		}
+		mantissa, exp = s[:i], e
+	}
+	digits := mantissa
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		digits = mantissa[:i] + mantissa[i+1:]
+		exp -= len(mantissa) - i - 1
+	}
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0" // 统一 -0 与 0
+	}
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+	digits = trimmed
+
+	// point 为小数点前的位数，与 JavaScript 的数字格式一样在合适的范围内使用普通写法
+	point := len(digits) + exp
+	switch {
+	case exp >= 0 && point <= 21:
+		return json.Number(sign + digits + strings.Repeat("0", exp))
+	case exp < 0 && point > 0:
+		return json.Number(sign + digits[:point] + "." + digits[point:])
+	case exp < 0 && point > -6:
+		return json.Number(sign + "0." + strings.Repeat("0", -point) + digits)
+	}
+	frac := ""
+	if len(digits) > 1 {
+		frac = "." + digits[1:]
+	}
+	return json.Number(sign + digits[:1] + frac + "e" + strconv.Itoa(point-1))
+}
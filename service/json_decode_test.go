@@ -0,0 +1,102 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestCompareJSON_NumberModes 测试不同数字方式下大整数和不同写法的数字的比较结果
+func TestCompareJSON_NumberModes(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		mode    NumberMode
+		json1   string
+		json2   string
+		changed bool
+	}{
+		{"float64下大整数精度丢失", NumberFloat64, `{"id":9007199254740993}`, `{"id":9007199254740992}`, false},
+		{"精确比较大整数", NumberExact, `{"id":9007199254740993}`, `{"id":9007199254740992}`, true},
+		{"按原始写法比较大整数", NumberLiteral, `{"id":9007199254740993}`, `{"id":9007199254740992}`, true},
+		{"精确比较时1与1.0相等", NumberExact, `{"id":1}`, `{"id":1.0}`, false},
+		{"精确比较时指数形式相等", NumberExact, `{"id":100}`, `{"id":1e2}`, false},
+		{"精确比较小数", NumberExact, `{"id":0.1}`, `{"id":0.10000000000000001}`, true},
+		{"按原始写法时1与1.0不同", NumberLiteral, `{"id":1}`, `{"id":1.0}`, true},
+		{"float64下1与1.0相等", NumberFloat64, `{"id":1}`, `{"id":1.0}`, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			diff, err := NewJSONDiffService(WithNumberMode(tc.mode)).CompareJSON(tc.json1, tc.json2)
+			if err != nil {
+				t.Fatalf("比较JSON时出错: %v", err)
+			}
+			if _, changed := diff.Changed["id"]; changed != tc.changed {
+				t.Errorf("id是否变更为%v，预期%v", changed, tc.changed)
+			}
+		})
+	}
+}
+
+// TestCompareJSON_ExactNumberValues 测试精确比较时差异记录中的数字类型和规范形式
+func TestCompareJSON_ExactNumberValues(t *testing.T) {
+	service := NewJSONDiffService(WithNumberMode(NumberExact), WithUnorderedArrays("tags"))
+	diff, err := service.CompareJSON(`{"id":9007199254740993,"tags":[1.0,2,3e0]}`, `{"id":9007199254740992.50,"tags":[3,2,1]}`)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if len(diff.Changes) != 1 {
+		t.Fatalf("预期只有id变更，但实际为%+v", diff.Changes)
+	}
+	c := diff.Changes[0]
+	if c.OldValue != json.Number("9007199254740993") || c.NewValue != json.Number("9007199254740992.5") || c.NewType != JSONNumber {
+		t.Errorf("差异记录为%+v", c)
+	}
+	if diff.Changed["id"] != "值变更: 9007199254740993 -> 9007199254740992.5" {
+		t.Errorf("变更描述为%s", diff.Changed["id"])
+	}
+}
+
+// TestApplyPatch_ExactNumbers 测试精确比较时应用补丁保留大整数
+func TestApplyPatch_ExactNumbers(t *testing.T) {
+	service := NewJSONDiffService(WithNumberMode(NumberExact))
+	result, err := service.ApplyPatch(`{"id":12345678901234567890,"n":1.50}`, `[{"op":"test","path":"/n","value":1.5},{"op":"replace","path":"/n","value":2}]`)
+	if err != nil {
+		t.Fatalf("应用补丁时出错: %v", err)
+	}
+	if result != `{"id":12345678901234567890,"n":2}` {
+		t.Errorf("应用补丁的结果为%s", result)
+	}
+
+	literal := NewJSONDiffService(WithNumberMode(NumberLiteral))
+	if _, err := literal.ApplyPatch(`{"n":1.50}`, `[{"op":"test","path":"/n","value":1.5}]`); err == nil {
+		t.Errorf("按原始写法比较时1.50与1.5不同，test操作应该失败")
+	}
+}
+
+// TestCanonicalNumber 测试数字的规范形式
+func TestCanonicalNumber(t *testing.T) {
+	testCases := map[string]string{
+		"0":           "0",
+		"-0.0":        "0",
+		"1.0":         "1",
+		"1e2":         "100",
+		"1.50":        "1.5",
+		"-0.00120":    "-0.0012",
+		"12.5e-1":     "1.25",
+		"1E+3":        "1000",
+		"1e20":        "100000000000000000000",
+		"1e21":        "1e21",
+		"1234e-10":    "1.234e-7",
+		"0.000001":    "0.000001",
+		"1e1000000":   "1e1000000",
+		"-25.0e-1000": "-2.5e-999",
+	}
+	for input, expected := range testCases {
+		if actual := canonicalNumber(json.Number(input)); string(actual) != expected {
+			t.Errorf("canonicalNumber(%s) = %s，预期%s", input, actual, expected)
+		}
+		if !json.Valid([]byte(expected)) {
+			t.Errorf("规范形式%s不是有效的JSON数字", expected)
+		}
+	}
+}
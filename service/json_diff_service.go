@@ -1,11 +1,9 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"reflect"
-	"regexp"
-	"strings"
+	"io"
 )
 
 // JSONDiffResult 表示两个JSON之间的差异结果
@@ -13,78 +11,243 @@ type JSONDiffResult struct {
 	Added   []string          `json:"added"`   // 在第二个JSON中新增的键路径
 	Removed []string          `json:"removed"` // 在第一个JSON中存在但在第二个中不存在的键路径
 	Changed map[string]string `json:"changed"` // 值发生变化的键路径和对应的变更信息
+	Moved   []MovedPath       `json:"moved"`   // 启用移动检测时，位置发生移动的值
+	Changes []Change          `json:"changes"` // 结构化的差异记录，包含差异类型以及旧值和新值
+
+	Truncated bool `json:"truncated,omitempty"` // 差异数量达到 WithMaxChanges 的上限，比较提前结束
+}
+
+// MovedPath 表示一个值从第一个JSON中的路径移动到了第二个JSON中的路径
+type MovedPath struct {
+	From string `json:"from"` // 值在第一个JSON中的路径
+	To   string `json:"to"`   // 值在第二个JSON中的路径
 }
 
 // JSONDiffService 提供JSON差异比较的服务接口
 type JSONDiffService interface {
+	Compare(ctx context.Context, json1, json2 string, opts ...Option) (JSONDiffResult, error)
+	CompareBytes(ctx context.Context, json1, json2 []byte, opts ...Option) (JSONDiffResult, error)
+	CompareReaders(ctx context.Context, r1, r2 io.Reader, opts ...Option) (JSONDiffResult, error)
+	CompareDecoded(ctx context.Context, v1, v2 interface{}, opts ...Option) (JSONDiffResult, error)
+	CompareValues(a, b interface{}, opts ...Option) (JSONDiffResult, error)
+	CompareStream(ctx context.Context, r1, r2 io.Reader, fn func(Change) error, opts ...Option) (StreamSummary, error)
+	CompareTo(ctx context.Context, json1, json2 string, sink ChangeSink, opts ...Option) error
+	Equal(ctx context.Context, json1, json2 string, opts ...Option) (bool, error)
 	CompareJSON(json1, json2 string) (JSONDiffResult, error)
 	CompareJSONWithIgnore(json1, json2 string, ignorePaths []string) (JSONDiffResult, error)
+	CreatePatch(json1, json2 string) (JSONPatch, error)
+	ApplyPatch(doc, patch string) (string, error)
+	ApplyMergePatch(doc, patch string) (string, error)
+	CreateMergePatch(json1, json2 string) (MergePatchResult, error)
+	Merge3(base, ours, theirs string, strategy ConflictStrategy) (MergeResult, error)
 }
 
 // jsonDiffServiceImpl JSON差异比较服务的具体实现
-type jsonDiffServiceImpl struct{}
+type jsonDiffServiceImpl struct {
+	config diffConfig
+}
+
+// diffConfig 保存比较服务的配置项
+type diffConfig struct {
+	arrayMode  ArrayDiffMode   // 数组比较策略
+	arrayKeys  []arrayKeyRule  // 按标识字段匹配元素的数组规则
+	unordered  []string        // 按多重集合（忽略顺序）比较的数组路径模式
+	moves      bool            // 是否检测值的移动
+	tests      bool            // 生成补丁时是否在 remove 和 replace 之前加入 test 操作
+	pathFormat PathFormat      // 差异结果和路径模式使用的路径格式
+	order      ResultOrder     // 差异结果的排列顺序
+	numbers    NumberMode      // 解析和比较数字的方式
+	tolerances []toleranceRule // 按路径设置的数字容差规则
+	include    []string        // 白名单模式下需要比较的路径模式
+	ignore     []string        // 忽略路径
+	maxBytes   int             // 每个JSON文本的最大字节数，0 表示不限制
+	maxDepth   int             // 最大嵌套层数，0 表示不限制
+	maxChanges int             // 最多报告的差异数量，0 表示不限制
+	detail     DetailLevel     // 差异结果的详细程度
+
+	comparators     []comparatorRule        // 按路径注册的比较器
+	typeComparators map[JSONType]Comparator // 按JSON类型注册的比较器
+
+	rules *compiledRules // 编译后的比较器、容差、标识字段和忽略顺序规则，由 with 生成
+}
+
+// Option 用于在创建服务时调整比较行为，也可以在调用 Compare 时只对单次比较生效
+type Option func(*diffConfig)
+
+// WithArrayDiffMode 设置数组的比较策略，默认按下标逐一比较
+func WithArrayDiffMode(mode ArrayDiffMode) Option {
+	return func(c *diffConfig) {
+		c.arrayMode = mode
+	}
+}
+
+// WithArrayKey 为匹配 pattern 的数组元素指定标识字段，元素按标识字段的值配对后再比较，
+// pattern 使用与忽略路径相同的语法描述数组本身或数组元素，例如 "employees" 或 "employees[*]"；
+// 指定多个字段时作为组合键使用；不是对象或缺少标识字段的元素只与另一侧值相同的同类元素配对，否则报告为移除或新增
+func WithArrayKey(pattern string, fields ...string) Option {
+	return func(c *diffConfig) {
+		c.arrayKeys = append(c.arrayKeys, arrayKeyRule{pattern: pattern, fields: fields})
+	}
+}
+
+// WithUnorderedArrays 指定按多重集合比较的数组路径，支持数组通配符 [*]，
+// 这些数组忽略元素顺序，只报告真正新增或移除的元素，重复元素按出现次数计算
+func WithUnorderedArrays(patterns ...string) Option {
+	return func(c *diffConfig) {
+		c.unordered = append(c.unordered, patterns...)
+	}
+}
+
+// WithMoveDetection 启用移动检测：通过子树的结构哈希识别被移动的值，以 Moved 报告而不是移除加新增。
+// 按下标比较无法识别数组内的移动，因此启用后默认策略的数组改为按 LCS 策略比较；
+// 按标识字段配对的数组中相对顺序变化的元素同样报告为移动，非空对象或数组被移动到其他位置时也会被识别
+func WithMoveDetection() Option {
+	return func(c *diffConfig) {
+		c.moves = true
+	}
+}
+
+// WithPatchTests 生成 JSON Patch 时在每个 remove 和 replace 操作之前加入校验旧值的 test 操作，
+// 带有这些操作的补丁可以用 JSONPatch.Invert 求逆
+func WithPatchTests() Option {
+	return func(c *diffConfig) {
+		c.tests = true
+	}
+}
 
 // NewJSONDiffService 创建一个新的JSON差异比较服务实例
-func NewJSONDiffService() JSONDiffService {
-	return &jsonDiffServiceImpl{}
+func NewJSONDiffService(opts ...Option) JSONDiffService {
+	s := &jsonDiffServiceImpl{}
+	for _, opt := range opts {
+		opt(&s.config)
+	}
+	return s
 }
 
 // CompareJSON 比较两个JSON字符串并返回它们之间的差异
 func (s *jsonDiffServiceImpl) CompareJSON(json1, json2 string) (JSONDiffResult, error) {
-	return s.CompareJSONWithIgnore(json1, json2, nil)
+	return s.Compare(context.Background(), json1, json2)
 }
 
-// CompareJSONWithIgnore 比较两个JSON字符串并返回它们之间的差异，支持忽略指定路径
+// CompareJSONWithIgnore 比较两个JSON字符串并返回它们之间的差异，支持忽略指定路径；
+// 忽略路径可以是按路径格式书写的模式，也可以是 $ 开头的 JSONPath 表达式，例如 $.users[?(@.role=='system')]，
+// JSONPath 分别对两个JSON求值，在任一JSON中被选中的路径都会被忽略
 func (s *jsonDiffServiceImpl) CompareJSONWithIgnore(json1, json2 string, ignorePaths []string) (JSONDiffResult, error) {
-	var obj1, obj2 interface{}
-	var result JSONDiffResult
-	result.Changed = make(map[string]string)
+	return s.Compare(context.Background(), json1, json2, WithIgnorePaths(ignorePaths...))
+}
 
-	// 解析第一个JSON字符串
-	if err := json.Unmarshal([]byte(json1), &obj1); err != nil {
-		return result, fmt.Errorf("解析第一个JSON失败: %v", err)
+// Compare 比较两个JSON字符串并返回它们之间的差异，opts 在创建服务时的配置之上只对本次比较生效。
+// 上下文取消时停止比较并返回上下文的错误
+func (s *jsonDiffServiceImpl) Compare(ctx context.Context, json1, json2 string, opts ...Option) (JSONDiffResult, error) {
+	config := s.config.with(opts)
+	if err := config.checkSize(len(json1), len(json2)); err != nil {
+		return newDiffResult(), err
+	}
+	order := config.newKeyOrder()
+	obj1, obj2, err := config.parseJSONPair(json1, json2, order)
+	if err != nil {
+		return newDiffResult(), err
 	}
+	return config.compareTrees(ctx, obj1, obj2, order)
+}
 
-	// 解析第二个JSON字符串
-	if err := json.Unmarshal([]byte(json2), &obj2); err != nil {
-		return result, fmt.Errorf("解析第二个JSON失败: %v", err)
+// newDiffResult 创建空的差异结果
+func newDiffResult() JSONDiffResult {
+	return JSONDiffResult{Changed: make(map[string]string)}
+}
+
+// compareTrees 比较两个解析后的文档，order 为解析时记录的对象成员顺序
+func (c *diffConfig) compareTrees(ctx context.Context, obj1, obj2 interface{}, order keyOrder) (JSONDiffResult, error) {
+	result := newDiffResult()
+	if err := c.checkDepth(obj1, obj2); err != nil {
+		return result, err
+	}
+	d, err := c.newDiffer(ctx, order, &result, obj1, obj2)
+	if err != nil {
+		return result, err
 	}
+	d.result = &result
 
 	// 比较两个对象
-	compareValues("", obj1, obj2, &result, ignorePaths)
+	d.compareValues("", obj1, obj2)
+	if d.err != nil {
+		return result, fmt.Errorf("比较被中断: %w", d.err)
+	}
+	// 结果被截断时新增和移除的子树并不完整，不再配对移动
+	if c.moves && !d.truncated {
+		d.detectSubtreeMoves()
+	}
+	result.Truncated = d.truncated
+	if c.order == OrderPath {
+		result.sortByPath(c.pathFormat)
+	}
+	if c.detail == DetailPaths {
+		result.stripValues()
+	}
 
 	return result, nil
 }
 
-// compareValues 递归比较两个值并记录差异
-func compareValues(path string, v1, v2 interface{}, result *JSONDiffResult, ignorePaths []string) {
-	// 检查当前路径是否应该被忽略
-	if shouldIgnorePath(path, ignorePaths) {
-		return
+// newDiffer 编译忽略和包含规则并创建把差异交给 sink 的比较器，JSONPath 规则对 docs 中的每个文档求值
+func (c *diffConfig) newDiffer(ctx context.Context, order keyOrder, sink ChangeSink, docs ...interface{}) (*jsonDiffer, error) {
+	ignore, err := c.compilePathRules(c.ignore, docs...)
+	if err != nil {
+		return nil, fmt.Errorf("解析忽略路径失败: %v", err)
+	}
+	include, err := c.compilePathRules(c.include, docs...)
+	if err != nil {
+		return nil, fmt.Errorf("解析包含路径失败: %v", err)
 	}
+	return &jsonDiffer{ctx: ctx, config: c, sink: sink, ignore: ignore, include: include, order: order}, nil
+}
 
-	// 处理nil值
-	if v1 == nil && v2 == nil {
+// jsonDiffer 保存单次比较过程中的配置、忽略路径和接收差异的 sink
+type jsonDiffer struct {
+	ctx     context.Context
+	config  *diffConfig
+	sink    ChangeSink      // 接收差异
+	result  *JSONDiffResult // sink 为差异结果时指向该结果，移动检测需要从中删除被配对的新增和移除
+	ignore  *pathMatcher    // 忽略路径，每次比较编译一次
+	include *pathMatcher    // 白名单模式下需要比较的路径，没有规则时比较所有路径
+	order   keyOrder        // 对象成员在文档中的顺序，按路径排序或比较已解码的值时为 nil
+
+	// 启用移动检测时记录被新增和移除的非空对象或数组，比较结束后再配对
+	addedTrees   []pathValue
+	removedTrees []pathValue
+
+	visited   int   // 已访问的节点数，用于定期检查上下文
+	recorded  int   // 已交给 sink 的差异数量
+	truncated bool  // 差异数量达到上限
+	err       error // 上下文取消或 sink 返回的错误
+}
+
+// compareValues 递归比较两个值并记录差异
+func (d *jsonDiffer) compareValues(path string, v1, v2 interface{}) {
+	if d.stopped() {
 		return
 	}
-	// 检查null到非null的变更
-	if v1 == nil {
-		result.Changed[path] = fmt.Sprintf("值变更: null -> %v", v2)
+	// 检查当前路径是否应该被忽略
+	if d.ignored(path) {
 		return
 	}
-	// 检查非null到null的变更
-	if v2 == nil {
-		result.Changed[path] = fmt.Sprintf("值变更: %v -> null", v1)
+	state := d.included(path)
+	if state == includeNone {
 		return
 	}
 
-	// 获取值的类型
-	type1 := reflect.TypeOf(v1)
-	type2 := reflect.TypeOf(v2)
-
-	// 如果类型不同
-	if type1.Kind() != type2.Kind() {
-		result.Changed[path] = fmt.Sprintf("类型变更: %v -> %v", type1.Kind(), type2.Kind())
+	// 处理nil值
+	if v1 == nil && v2 == nil {
+		return
+	}
+	// 如果类型不同（包括null与非null之间的变更）
+	if jsonTypeOf(v1) != jsonTypeOf(v2) {
+		if state == includeAncestor {
+			// 祖先路径本身不报告差异，其中需要比较的后代分别报告为移除和新增
+			d.eachChild(path, v1, d.recordRemoved)
+			d.eachChild(path, v2, d.recordAdded)
+			return
+		}
+		d.recordValueChange(path, v1, v2)
 		return
 	}
 
@@ -95,118 +258,114 @@ func compareValues(path string, v1, v2 interface{}, result *JSONDiffResult, igno
 		m2 := v2.(map[string]interface{})
 
 		// 检查第一个对象中存在但第二个对象中不存在的键
-		for k, v := range t {
-			fullPath := buildPath(path, k)
+		for _, k := range d.order.keys(t) {
+			v := t[k]
+			fullPath := d.keyPath(path, k)
 			if _, exists := m2[k]; !exists {
-				if !shouldIgnorePath(fullPath, ignorePaths) {
-					result.Removed = append(result.Removed, fullPath)
-				}
+				d.recordRemoved(fullPath, v)
 			} else {
 				// 递归比较值
-				compareValues(fullPath, v, m2[k], result, ignorePaths)
+				d.compareValues(fullPath, v, m2[k])
 			}
 		}
 
 		// 检查第二个对象中存在但第一个对象中不存在的键
-		for k := range m2 {
+		for _, k := range d.order.keys(m2) {
 			if _, exists := t[k]; !exists {
-				fullPath := buildPath(path, k)
-				if !shouldIgnorePath(fullPath, ignorePaths) {
-					result.Added = append(result.Added, fullPath)
-				}
+				d.recordAdded(d.keyPath(path, k), m2[k])
 			}
 		}
 
 	case []interface{}:
-		// 比较数组
-		a2 := v2.([]interface{})
-
-		// 如果数组长度不同，记录长度变更
-		if len(t) != len(a2) {
-			result.Changed[path] = fmt.Sprintf("数组长度变更: %d -> %d", len(t), len(a2))
-		}
-
-		// 比较对应位置的元素（比较到较短数组的长度）
-		minLen := len(t)
-		if len(a2) < minLen {
-			minLen = len(a2)
-		}
-
-		for i := 0; i < minLen; i++ {
-			compareValues(fmt.Sprintf("%s[%d]", path, i), t[i], a2[i], result, ignorePaths)
-		}
-
-		// 处理数组长度不同的情况
-		if len(t) > len(a2) {
-			// 第一个数组更长，标记多余元素为移除
-			for i := len(a2); i < len(t); i++ {
-				arrayPath := fmt.Sprintf("%s[%d]", path, i)
-				if !shouldIgnorePath(arrayPath, ignorePaths) {
-					result.Removed = append(result.Removed, arrayPath)
-				}
-			}
-		} else if len(t) < len(a2) {
-			// 第二个数组更长，标记多余元素为新增
-			for i := len(t); i < len(a2); i++ {
-				arrayPath := fmt.Sprintf("%s[%d]", path, i)
-				if !shouldIgnorePath(arrayPath, ignorePaths) {
-					result.Added = append(result.Added, arrayPath)
-				}
-			}
-		}
+		// 比较数组，根据配置选择比较策略
+		d.compareArrays(path, t, v2.([]interface{}))
 
 	default:
 		// 比较基本类型值
-		if !reflect.DeepEqual(v1, v2) {
-			result.Changed[path] = fmt.Sprintf("值变更: %v -> %v", v1, v2)
+		if !d.config.primitiveEqual(path, v1, v2) {
+			d.recordValueChange(path, v1, v2)
 		}
 	}
 }
 
-// buildPath 构建完整的键路径
-func buildPath(parentPath, key string) string {
-	if parentPath == "" {
-		return key
+// recordAdded 记录新增的路径（被忽略的路径除外），白名单模式下祖先路径改为记录其中需要比较的后代
+func (d *jsonDiffer) recordAdded(path string, value interface{}) {
+	if d.ignored(path) {
+		return
+	}
+	switch d.included(path) {
+	case includeNone:
+		return
+	case includeAncestor:
+		d.eachChild(path, value, d.recordAdded)
+		return
+	}
+	d.record(Change{Kind: ChangeAdded, Path: path, NewValue: value, NewType: jsonTypeOf(value)})
+	if d.config.moves && isNonEmptyContainer(value) {
+		d.addedTrees = append(d.addedTrees, pathValue{path: path, value: value})
 	}
-	return fmt.Sprintf("%s.%s", parentPath, key)
 }
 
-// shouldIgnorePath 检查给定路径是否应该被忽略，支持数组通配符 [*]
-func shouldIgnorePath(path string, ignorePaths []string) bool {
-	if ignorePaths == nil {
-		return false
+// recordRemoved 记录移除的路径（被忽略的路径除外），白名单模式下祖先路径改为记录其中需要比较的后代
+func (d *jsonDiffer) recordRemoved(path string, value interface{}) {
+	if d.ignored(path) {
+		return
 	}
-
-	for _, ignorePath := range ignorePaths {
-		if matchesPath(path, ignorePath) {
-			return true
-		}
+	switch d.included(path) {
+	case includeNone:
+		return
+	case includeAncestor:
+		d.eachChild(path, value, d.recordRemoved)
+		return
+	}
+	d.record(Change{Kind: ChangeRemoved, Path: path, OldValue: value, OldType: jsonTypeOf(value)})
+	if d.config.moves && isNonEmptyContainer(value) {
+		d.removedTrees = append(d.removedTrees, pathValue{path: path, value: value})
 	}
-	return false
 }
 
-// matchesPath 检查路径是否匹配忽略模式，支持数组通配符
-func matchesPath(path, pattern string) bool {
-	// 精确匹配
-	if path == pattern {
-		return true
+// recordMoved 记录值的移动（任一路径被忽略时不记录）
+func (d *jsonDiffer) recordMoved(from, to string, v1, v2 interface{}) {
+	if d.ignored(from) || d.ignored(to) {
+		return
 	}
+	d.record(Change{
+		Kind:     ChangeMoved,
+		Path:     to,
+		From:     from,
+		OldValue: v1,
+		NewValue: v2,
+		OldType:  jsonTypeOf(v1),
+		NewType:  jsonTypeOf(v2),
+	})
+}
 
-	// 如果模式不包含通配符，则只进行精确匹配
-	if !strings.Contains(pattern, "[*]") {
-		return false
-	}
+// keyPath 按配置的路径格式构建对象成员的路径，同时由父路径推进忽略和包含规则的匹配状态
+func (d *jsonDiffer) keyPath(parent, key string) string {
+	f := d.config.pathFormat
+	path := f.key(parent, key)
+	seg := f.keySegment(key)
+	d.ignore.descend(parent, path, seg)
+	d.include.descend(parent, path, seg)
+	return path
+}
 
-	// 转换通配符模式为正则表达式
-	// 将 [*] 替换为 \[\d+\] 来匹配任意数字索引
-	regexPattern := regexp.QuoteMeta(pattern)
-	regexPattern = strings.ReplaceAll(regexPattern, `\[\*\]`, `\[\d+\]`)
-	regexPattern = "^" + regexPattern + "$"
+// indexPath 按配置的路径格式构建数组元素的路径，同时由父路径推进忽略和包含规则的匹配状态
+func (d *jsonDiffer) indexPath(parent string, index int) string {
+	f := d.config.pathFormat
+	path := f.index(parent, index)
+	seg := f.indexSegment(index)
+	d.ignore.descend(parent, path, seg)
+	d.include.descend(parent, path, seg)
+	return path
+}
 
-	matched, err := regexp.MatchString(regexPattern, path)
-	if err != nil {
-		return false
-	}
+// ignored 检查给定路径是否匹配任一忽略路径
+func (d *jsonDiffer) ignored(path string) bool {
+	return d.ignore.matches(path)
+}
 
-	return matched
+// matchesPath 按默认的点号格式检查路径是否匹配忽略模式，支持数组通配符 [*]
+func matchesPath(path, pattern string) bool {
+	return PathDotted.matches(path, pattern)
 }
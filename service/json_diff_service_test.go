@@ -1,6 +1,9 @@
 package service
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -719,3 +722,84 @@ func TestMatchesPath_WildcardPatterns(t *testing.T) {
 		})
 	}
 }
+
+// benchmarkIgnorePaths 基准测试使用的忽略路径
+var benchmarkIgnorePaths = []string{"users[*].updatedAt", "users[*].profile.tags[*]", "**.traceId", "meta.*", "users[*].x-*"}
+
+// benchmarkDocuments 生成包含 n 个用户的两个JSON，用于基准测试
+func benchmarkDocuments(n int) (string, string) {
+	build := func(version int) string {
+		var b strings.Builder
+		b.WriteString(`{"meta":{"traceId":"t","page":1},"users":[`)
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, `{"id":%d,"name":"user%d","updatedAt":"%d","x-request":"%d","traceId":"%d",`, i, i, version, version, version)
+			fmt.Fprintf(&b, `"profile":{"age":%d,"tags":["a","b","%d"],"address":{"city":"c%d","zip":"%d"}}}`, i%90, version, i, i*version)
+		}
+		b.WriteString(`]}`)
+		return b.String()
+	}
+	return build(1), build(2)
+}
+
+// BenchmarkCompareJSONWithIgnore 测试带忽略路径比较大JSON的性能
+func BenchmarkCompareJSONWithIgnore(b *testing.B) {
+	json1, json2 := benchmarkDocuments(2000)
+	service := NewJSONDiffService()
+	b.SetBytes(int64(len(json1) + len(json2)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := service.CompareJSONWithIgnore(json1, json2, benchmarkIgnorePaths); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkIgnoreMatching 对比遍历文档时逐个匹配忽略模式和使用编译后的匹配器的性能
+func BenchmarkIgnoreMatching(b *testing.B) {
+	json1, _ := benchmarkDocuments(2000)
+	var doc interface{}
+	if err := json.Unmarshal([]byte(json1), &doc); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("逐个匹配模式", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			walkBenchmarkPaths("", doc, func(parent, path string, seg pathSegment) {
+				PathDotted.matchesAny(path, benchmarkIgnorePaths)
+			})
+		}
+	})
+	b.Run("编译后的匹配器", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			m := newPathMatcher(PathDotted)
+			for _, pattern := range benchmarkIgnorePaths {
+				m.addPattern(pattern)
+			}
+			walkBenchmarkPaths("", doc, func(parent, path string, seg pathSegment) {
+				m.descend(parent, path, seg)
+				m.matches(path)
+			})
+		}
+	})
+}
+
+// walkBenchmarkPaths 按深度优先的顺序对文档中的每个路径调用 visit
+func walkBenchmarkPaths(path string, v interface{}, visit func(parent, path string, seg pathSegment)) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			childPath := PathDotted.key(path, k)
+			visit(path, childPath, PathDotted.keySegment(k))
+			walkBenchmarkPaths(childPath, child, visit)
+		}
+	case []interface{}:
+		for i, child := range t {
+			childPath := PathDotted.index(path, i)
+			visit(path, childPath, PathDotted.indexSegment(i))
+			walkBenchmarkPaths(childPath, child, visit)
+		}
+	}
+}
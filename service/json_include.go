@@ -0,0 +1,55 @@
+package service
+
+// includeState 表示路径与包含规则的关系
+type includeState int
+
+const (
+	includeNone     includeState = iota // 路径及其后代都不需要比较
+	includeAncestor                     // 路径是需要比较的路径的祖先，只遍历而不报告差异
+	includeAll                          // 路径本身或其祖先匹配包含规则，整棵子树都需要比较
+)
+
+// WithIncludePaths 只比较匹配 patterns 的路径（白名单模式），其他路径全部跳过。
+// 模式使用与忽略路径相同的语法，也可以是 $ 开头的 JSONPath 表达式；匹配路径下的整棵子树都会比较，
+// 匹配路径的祖先只遍历而不报告差异，祖先整体新增、移除或改变类型时，其中匹配的后代分别报告为新增和移除。
+// 同时指定忽略路径时忽略优先，例如包含 items[*] 并忽略 items[*].updatedAt；白名单只影响比较结果，不影响生成的补丁
+func WithIncludePaths(patterns ...string) Option {
+	return func(c *diffConfig) {
+		c.include = append(c.include, patterns...)
+	}
+}
+
+// includeState 检查路径与包含规则的关系：有规则匹配路径本身或它的某个祖先时返回 includeAll，
+// 还有规则可能匹配它的后代时返回 includeAncestor
+func (m *pathMatcher) includeState(path string) includeState {
+	s := m.state(path)
+	switch {
+	case s.matched || s.inherited:
+		return includeAll
+	case len(s.positions) > 0:
+		return includeAncestor
+	}
+	return includeNone
+}
+
+// included 检查路径是否需要比较，没有包含规则时所有路径都需要比较
+func (d *jsonDiffer) included(path string) includeState {
+	if d.include.empty() {
+		return includeAll
+	}
+	return d.include.includeState(path)
+}
+
+// eachChild 按成员顺序对对象的每个成员或数组的每个元素调用 fn
+func (d *jsonDiffer) eachChild(path string, v interface{}, fn func(path string, v interface{})) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for _, k := range d.order.keys(t) {
+			fn(d.keyPath(path, k), t[k])
+		}
+	case []interface{}:
+		for i, e := range t {
+			fn(d.indexPath(path, i), e)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestCompareJSON_IncludePaths 测试只比较包含路径，祖先路径只遍历而不报告
+func TestCompareJSON_IncludePaths(t *testing.T) {
+	service := NewJSONDiffService(WithIncludePaths("status", "items[*].sku", "total"))
+	json1 := `{"status":"open","total":10,"items":[{"sku":"a","qty":1},{"sku":"b","qty":1}],"debug":{"trace":1},"note":"x"}`
+	json2 := `{"status":"paid","total":10,"items":[{"sku":"a","qty":2},{"sku":"c","qty":1},{"sku":"d"}],"debug":{"trace":2}}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	expected := []string{"status", "items[1].sku"}
+	if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("变更路径为%v，预期%v", paths, expected)
+	}
+	// 新增的数组元素是祖先路径，只报告其中的 sku
+	if !reflect.DeepEqual(diff.Added, []string{"items[2].sku"}) {
+		t.Errorf("新增路径为%v，预期[items[2].sku]", diff.Added)
+	}
+	if len(diff.Removed) != 0 {
+		t.Errorf("note不在包含范围内，不应报告移除，实际为%v", diff.Removed)
+	}
+}
+
+// TestCompareJSON_IncludeSubtree 测试包含路径下的整棵子树都参与比较，以及与忽略路径同时使用
+func TestCompareJSON_IncludeSubtree(t *testing.T) {
+	service := NewJSONDiffService(WithIncludePaths("user", "$.orders[?(@.open==true)]"))
+	json1 := `{"user":{"name":"a","meta":{"updatedAt":1,"v":1}},"orders":[{"open":true,"n":1},{"open":false,"n":1}],"x":1}`
+	json2 := `{"user":{"name":"b","meta":{"updatedAt":2,"v":2}},"orders":[{"open":true,"n":2},{"open":false,"n":2}],"x":2}`
+
+	diff, err := service.CompareJSONWithIgnore(json1, json2, []string{"**.updatedAt"})
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	expected := []string{"user.name", "user.meta.v", "orders[0].n"}
+	if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("变更路径为%v，预期%v", paths, expected)
+	}
+}
+
+// TestCompareJSON_IncludeAncestorChanges 测试祖先路径整体新增、移除或改变类型时只报告其中的包含路径
+func TestCompareJSON_IncludeAncestorChanges(t *testing.T) {
+	service := NewJSONDiffService(WithIncludePaths("a.b.c", "list[*].id"), WithArrayKey("list", "id"))
+	json1 := `{"a":{"b":{"c":1,"d":1}},"list":[{"id":1,"v":1},{"id":2,"v":2}]}`
+	json2 := `{"a":"flat","list":[{"id":2,"v":3}],"extra":{"b":{"c":1}}}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	removed := append([]string(nil), diff.Removed...)
+	sort.Strings(removed)
+	if !reflect.DeepEqual(removed, []string{"a.b.c", "list[0].id"}) {
+		t.Errorf("移除路径为%v，预期[a.b.c list[0].id]", removed)
+	}
+	if len(diff.Added) != 0 || len(diff.Changed) != 0 {
+		t.Errorf("预期没有新增和变更，实际为%v和%v", diff.Added, diff.Changed)
+	}
+
+	// 数组长度变化不属于包含路径
+	service = NewJSONDiffService(WithIncludePaths("list[*].id"))
+	diff, err = service.CompareJSON(`{"list":[{"id":1}]}`, `{"list":[{"id":1},{"id":2}]}`)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if len(diff.Changed) != 0 || !reflect.DeepEqual(diff.Added, []string{"list[1].id"}) {
+		t.Errorf("预期只新增list[1].id，实际为%+v", diff.Changes)
+	}
+}
+
+// TestPathMatcher_IncludeState 测试路径与包含规则的关系
+func TestPathMatcher_IncludeState(t *testing.T) {
+	rules := newPathMatcher(PathDotted)
+	for _, pattern := range []string{"items[*].sku", "**.meta.v", "config"} {
+		rules.addPattern(pattern)
+	}
+	testCases := []struct {
+		path     string
+		expected includeState
+	}{
+		{"", includeAncestor},
+		{"items", includeAncestor},
+		{"items[0]", includeAncestor},
+		{"items[0].sku", includeAll},
+		{"items[0].qty", includeAncestor}, // **.meta.v 可能匹配其中的路径
+		{"config", includeAll},
+		{"config.x[1]", includeAll},
+		{"a.b.meta.v", includeAll},
+		{"a.b.meta.v.w", includeAll},
+	}
+	for _, tc := range testCases {
+		if actual := rules.includeState(tc.path); actual != tc.expected {
+			t.Errorf("includeState(%q) = %d，预期%d", tc.path, actual, tc.expected)
+		}
+	}
+
+	rules = newPathMatcher(PathPointer)
+	rules.addPattern("/items/*/sku")
+	for path, expected := range map[string]includeState{"": includeAncestor, "/items/0": includeAncestor, "/items/0/sku": includeAll, "/total": includeNone, "/items/0/qty": includeNone} {
+		if actual := rules.includeState(path); actual != expected {
+			t.Errorf("includeState(%q) = %d，预期%d", path, actual, expected)
+		}
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal([]byte(`{"a":[{},{"b":{"c":1}}],"ab":1}`), &doc); err != nil {
+		t.Fatalf("解析测试文档失败: %v", err)
+	}
+	rules, err := (&diffConfig{}).compilePathRules([]string{"$.a[1].b"}, doc)
+	if err != nil {
+		t.Fatalf("编译包含路径时出错: %v", err)
+	}
+	for path, expected := range map[string]includeState{"": includeAncestor, "a": includeAncestor, "a[1]": includeAncestor, "a[1].b.c": includeAll, "a[0]": includeNone, "ab": includeNone} {
+		if actual := rules.includeState(path); actual != expected {
+			t.Errorf("includeState(%q) = %d，预期%d", path, actual, expected)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// CompareBytes 比较两个字节形式的JSON文档，用法与 Compare 相同；
+// json.RawMessage 可以直接传入，不需要先转换为字符串
+func (s *jsonDiffServiceImpl) CompareBytes(ctx context.Context, json1, json2 []byte, opts ...Option) (JSONDiffResult, error) {
+	config := s.config.with(opts)
+	if err := config.checkSize(len(json1), len(json2)); err != nil {
+		return newDiffResult(), err
+	}
+	order := config.newKeyOrder()
+	var objs [2]interface{}
+	for i, doc := range [][]byte{json1, json2} {
+		v, err := config.parseBytes(doc, order)
+		if err != nil {
+			return newDiffResult(), fmt.Errorf("解析第%s个JSON失败: %v", ordinals[i], err)
+		}
+		objs[i] = v
+	}
+	return config.compareTrees(ctx, objs[0], objs[1], order)
+}
+
+// CompareReaders 从两个 io.Reader 中分别读取一个JSON文档并比较，例如两个 HTTP 响应的 Body，
+// 文档边读取边解析，不会先整体读入内存；每个 Reader 在文档之后只允许有空白。
+// WithMaxDocumentSize 限制从每个 Reader 读取的字节数，超过时停止读取并返回 ErrLimitExceeded
+func (s *jsonDiffServiceImpl) CompareReaders(ctx context.Context, r1, r2 io.Reader, opts ...Option) (JSONDiffResult, error) {
+	config := s.config.with(opts)
+	order := config.newKeyOrder()
+	var objs [2]interface{}
+	for i, r := range []io.Reader{r1, r2} {
+		limited := &limitedReader{r: r, limit: config.maxBytes, doc: i}
+		v, err := config.parseReader(limited, order)
+		if limited.err != nil {
+			return newDiffResult(), limited.err
+		}
+		if err != nil {
+			return newDiffResult(), fmt.Errorf("解析第%s个JSON失败: %v", ordinals[i], err)
+		}
+		objs[i] = v
+	}
+	return config.compareTrees(ctx, objs[0], objs[1], order)
+}
+
+// CompareDecoded 比较两个已经解码的JSON值，例如 json.Unmarshal 到 interface{} 得到的结果，不需要重新编码为文本。
+// 值中只能包含 map[string]interface{}、[]interface{}、string、float64、json.Number、bool、nil 和 json.RawMessage，
+// 数字按配置的数字方式转换后比较；对象成员没有文档顺序，按字典序比较和报告。传入的值不会被修改
+func (s *jsonDiffServiceImpl) CompareDecoded(ctx context.Context, v1, v2 interface{}, opts ...Option) (JSONDiffResult, error) {
+	config := s.config.with(opts)
+	var objs [2]interface{}
+	for i, v := range []interface{}{v1, v2} {
+		obj, _, err := config.decodedValue("", v)
+		if err != nil {
+			return newDiffResult(), fmt.Errorf("第%s个值无效: %v", ordinals[i], err)
+		}
+		objs[i] = obj
+	}
+	return config.compareTrees(ctx, objs[0], objs[1], nil)
+}
+
+// limitedReader 限制从 Reader 中读取的字节数，超过上限时记录 ErrLimitExceeded 错误，limit 为 0 表示不限制
+type limitedReader struct {
+	r     io.Reader
+	limit int
+	doc   int // 文档的序号，用于错误信息
+	read  int
+	err   error
+}
+
+// Read 读取数据，最多只读取超过上限的 1 个字节用于判断是否超过上限
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	if l.limit > 0 && len(p) > l.limit-l.read+1 {
+		p = p[:l.limit-l.read+1]
+	}
+	n, err := l.r.Read(p)
+	l.read += n
+	if l.limit > 0 && l.read > l.limit {
+		l.err = fmt.Errorf("%w: 第%s个JSON大小超过 %d 字节", ErrLimitExceeded, ordinals[l.doc], l.limit)
+		return 0, l.err
+	}
+	return n, err
+}
+
+// jsonNumberPattern 匹配JSON数字的语法
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][-+]?\d+)?$`)
+
+// decodedValue 检查已解码的值只包含JSON值的类型，并按配置的数字方式转换其中的数字；
+// 需要转换时复制所在的对象和数组，changed 表示返回的值与 v 不同
+func (c *diffConfig) decodedValue(path string, v interface{}) (value interface{}, changed bool, err error) {
+	switch t := v.(type) {
+	case nil, bool, string:
+		return v, false, nil
+	case float64:
+		if c.numbers == NumberFloat64 {
+			return v, false, nil
+		}
+		data, err := json.Marshal(t)
+		if err != nil {
+			return nil, false, fmt.Errorf("路径 %q 的数字无效: %v", path, err)
+		}
+		return c.canonical(json.Number(data)), true, nil
+	case json.Number:
+		if !jsonNumberPattern.MatchString(string(t)) {
+			return nil, false, fmt.Errorf("路径 %q 的数字 %q 无效", path, string(t))
+		}
+		switch c.numbers {
+		case NumberFloat64:
+			f, err := t.Float64()
+			if err != nil {
+				return nil, false, fmt.Errorf("路径 %q 的数字无效: %v", path, err)
+			}
+			return f, true, nil
+		case NumberExact:
+			n := canonicalNumber(t)
+			return n, n != t, nil
+		}
+		return v, false, nil
+	case json.RawMessage:
+		obj, err := c.parseBytes(t, nil)
+		if err != nil {
+			return nil, false, fmt.Errorf("路径 %q 的 json.RawMessage 无效: %v", path, err)
+		}
+		return obj, true, nil
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for _, k := range sortedKeys(t) {
+			e, changed, err := c.decodedValue(c.pathFormat.key(path, k), t[k])
+			if err != nil {
+				return nil, false, err
+			}
+			if changed && out == nil {
+				out = make(map[string]interface{}, len(t))
+				for k, e := range t {
+					out[k] = e
+				}
+			}
+			if changed {
+				out[k] = e
+			}
+		}
+		if out == nil {
+			return v, false, nil
+		}
+		return out, true, nil
+	case []interface{}:
+		var out []interface{}
+		for i, e := range t {
+			e, changed, err := c.decodedValue(c.pathFormat.index(path, i), e)
+			if err != nil {
+				return nil, false, err
+			}
+			if changed && out == nil {
+				out = append([]interface{}(nil), t...)
+			}
+			if changed {
+				out[i] = e
+			}
+		}
+		if out == nil {
+			return v, false, nil
+		}
+		return out, true, nil
+	}
+	return nil, false, fmt.Errorf("路径 %q 的值类型 %T 不是JSON值", path, v)
+}
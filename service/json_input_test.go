@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCompareInputs_SameResult 测试字符串、字节、Reader 和已解码的值得到相同的差异
+func TestCompareInputs_SameResult(t *testing.T) {
+	service := NewJSONDiffService(WithResultOrder(OrderPath), WithIgnorePaths("ts"))
+	json1 := `{"ts":1,"name":"a","tags":["x","y"],"n":1,"nested":{"k":true}}`
+	json2 := `{"ts":2,"name":"b","tags":["x"],"n":2,"nested":{"k":false,"new":null}}`
+	ctx := context.Background()
+
+	expected, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+
+	var v1, v2 interface{}
+	if err := json.Unmarshal([]byte(json1), &v1); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(json2), &v2); err != nil {
+		t.Fatal(err)
+	}
+	raw1, raw2 := json.RawMessage(json1), json.RawMessage(json2)
+
+	compare := map[string]func() (JSONDiffResult, error){
+		"字节": func() (JSONDiffResult, error) {
+			return service.CompareBytes(ctx, raw1, raw2)
+		},
+		"Reader": func() (JSONDiffResult, error) {
+			return service.CompareReaders(ctx, strings.NewReader(json1), strings.NewReader(json2))
+		},
+		"已解码的值": func() (JSONDiffResult, error) {
+			return service.CompareDecoded(ctx, v1, v2)
+		},
+	}
+	for name, fn := range compare {
+		diff, err := fn()
+		if err != nil {
+			t.Fatalf("%s: 比较时出错: %v", name, err)
+		}
+		if !reflect.DeepEqual(diff, expected) {
+			t.Errorf("%s: 差异为%+v，预期%+v", name, diff.Changes, expected.Changes)
+		}
+	}
+
+	// 已解码的值中的 json.RawMessage 被解析后比较
+	diff, err := service.CompareDecoded(ctx, map[string]interface{}{"doc": raw1}, map[string]interface{}{"doc": v1})
+	if err != nil || len(diff.Changes) != 0 {
+		t.Errorf("json.RawMessage与解码后的值应该相等，实际差异为%+v，错误为%v", diff.Changes, err)
+	}
+}
+
+// TestCompareDecoded_Numbers 测试已解码的值中的数字按配置的数字方式比较，且传入的值不被修改
+func TestCompareDecoded_Numbers(t *testing.T) {
+	v1 := map[string]interface{}{"a": 1.0, "b": []interface{}{json.Number("1e2")}}
+	v2 := map[string]interface{}{"a": json.Number("1.0"), "b": []interface{}{100.0}}
+
+	diff, err := NewJSONDiffService(WithNumberMode(NumberExact)).CompareDecoded(context.Background(), v1, v2)
+	if err != nil {
+		t.Fatalf("比较时出错: %v", err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Errorf("按精确值比较应该相等，实际差异为%+v", diff.Changes)
+	}
+	if v1["b"].([]interface{})[0] != json.Number("1e2") || v2["a"] != json.Number("1.0") {
+		t.Errorf("传入的值被修改: %v %v", v1, v2)
+	}
+
+	diff, err = NewJSONDiffService(WithNumberMode(NumberLiteral)).CompareDecoded(context.Background(), v1, v2)
+	if err != nil {
+		t.Fatalf("比较时出错: %v", err)
+	}
+	if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, []string{"a", "b[0]"}) {
+		t.Errorf("按原始写法比较时变更路径为%v，预期[a b[0]]", paths)
+	}
+}
+
+// TestCompareInputs_Errors 测试无效输入和大小限制
+func TestCompareInputs_Errors(t *testing.T) {
+	service := NewJSONDiffService()
+	ctx := context.Background()
+
+	if _, err := service.CompareBytes(ctx, []byte(`{}`), []byte(`{`)); err == nil || !strings.Contains(err.Error(), "第二个JSON") {
+		t.Errorf("无效的第二个JSON应该返回错误，实际为%v", err)
+	}
+	if _, err := service.CompareReaders(ctx, strings.NewReader(`{} {}`), strings.NewReader(`{}`)); err == nil {
+		t.Errorf("文档之后有多余的内容时应该返回错误")
+	}
+	for _, v := range []interface{}{
+		map[string]interface{}{"a": []interface{}{1}},
+		map[string]interface{}{"a": json.Number("01")},
+		json.RawMessage(`{"a":`),
+	} {
+		if _, err := service.CompareDecoded(ctx, v, nil); err == nil {
+			t.Errorf("%#v 不是有效的JSON值，应该返回错误", v)
+		}
+	}
+
+	// Reader 读取超过上限时停止读取
+	large := strings.NewReader(`{"a":"` + strings.Repeat("x", 1<<20) + `"}`)
+	_, err := service.CompareReaders(ctx, strings.NewReader(`{}`), large, WithMaxDocumentSize(100))
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("超过大小上限时应该返回ErrLimitExceeded，实际为%v", err)
+	}
+	if read := int64(1<<20) - int64(large.Len()); read > 1<<16 {
+		t.Errorf("超过上限后不应该继续读取，已读取%d字节", read)
+	}
+	if _, err := service.CompareBytes(ctx, []byte(`{}`), []byte(`{"a":1}`), WithMaxDocumentSize(5)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("超过大小上限时应该返回ErrLimitExceeded，实际为%v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import "fmt"
+
+// Invert 返回反方向（从第二个JSON到第一个JSON）的差异结果：新增与移除互换，
+// 旧值与新值互换，移动的来源与目标互换。
+// 注意配对后的数组元素的嵌套差异沿用原结果中的下标，按 LCS 或标识字段比较时可能与重新比较得到的下标不同
+func (r JSONDiffResult) Invert() JSONDiffResult {
+	inverted := JSONDiffResult{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: make(map[string]string),
+		Moved:   []MovedPath{},
+		Changes: []Change{},
+	}
+	for _, c := range r.Changes {
+		inverted.appendChange(c.invert())
+	}
+	return inverted
+}
+
+// invert 返回反方向的差异记录
+func (c Change) invert() Change {
+	inverted := Change{
+		Kind:     c.Kind,
+		Path:     c.Path,
+		OldValue: c.NewValue,
+		NewValue: c.OldValue,
+		OldType:  c.NewType,
+		NewType:  c.OldType,
+	}
+	switch c.Kind {
+	case ChangeAdded:
+		inverted.Kind = ChangeRemoved
+	case ChangeRemoved:
+		inverted.Kind = ChangeAdded
+	case ChangeMoved:
+		inverted.Path, inverted.From = c.From, c.Path
+	}
+	return inverted
+}
+
+// Invert 返回撤销该补丁的补丁，把它应用到原补丁的结果上可以得到原文档。
+// remove 和 replace 操作之前必须有校验同一路径旧值的 test 操作（见 WithPatchTests），
+// 否则无法得知被删除或替换的值，返回标明该操作的 *PatchError。
+// add 和 copy 操作按写入新路径处理，撤销时直接移除目标路径。
+// 不含 copy 操作时，生成的逆补丁同样带有所需的 test 操作，可以再次求逆
+func (p JSONPatch) Invert() (JSONPatch, error) {
+	var inverse []JSONPatch
+	for i := 0; i < len(p); i++ {
+		op := p[i]
+		switch op.Op {
+		case PatchAdd:
+			inverse = append(inverse, JSONPatch{
+				{Op: PatchTest, Path: op.Path, Value: op.Value},
+				{Op: PatchRemove, Path: op.Path},
+			})
+		case PatchCopy:
+			inverse = append(inverse, JSONPatch{{Op: PatchRemove, Path: op.Path}})
+		case PatchMove:
+			inverse = append(inverse, JSONPatch{{Op: PatchMove, From: op.Path, Path: op.From}})
+		case PatchRemove, PatchReplace:
+			return nil, &PatchError{Index: i, Op: op.Op, Path: op.Path, Err: fmt.Errorf("缺少记录旧值的 test 操作，无法求逆")}
+		case PatchTest:
+			// 紧跟着同一路径 remove 或 replace 操作的 test 记录了旧值，两者一起求逆
+			if i+1 < len(p) && p[i+1].Path == op.Path {
+				switch next := p[i+1]; next.Op {
+				case PatchRemove:
+					inverse = append(inverse, JSONPatch{{Op: PatchAdd, Path: op.Path, Value: op.Value}})
+					i++
+					continue
+				case PatchReplace:
+					inverse = append(inverse, JSONPatch{
+						{Op: PatchTest, Path: op.Path, Value: next.Value},
+						{Op: PatchReplace, Path: op.Path, Value: op.Value},
+					})
+					i++
+					continue
+				}
+			}
+			// 单独的 test 不修改文档，在逆补丁的对应位置同样成立
+			inverse = append(inverse, JSONPatch{op})
+		default:
+			return nil, &PatchError{Index: i, Op: op.Op, Path: op.Path, Err: fmt.Errorf("未知的操作类型")}
+		}
+	}
+
+	result := JSONPatch{}
+	for i := len(inverse) - 1; i >= 0; i-- {
+		result = append(result, inverse[i]...)
+	}
+	return result, nil
+}
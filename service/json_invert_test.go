@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestJSONDiffResult_Invert 测试反转后的差异结果与反方向比较的结果一致
+func TestJSONDiffResult_Invert(t *testing.T) {
+	service := NewJSONDiffService()
+	json1 := `{"name":"Alice","age":30,"tags":["a"],"note":null,"address":{"city":"NY"}}`
+	json2 := `{"name":42,"age":31,"tags":["a","b"],"note":"hi","email":"a@b.c"}`
+
+	forward, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	backward, err := service.CompareJSON(json2, json1)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+
+	inverted := forward.Invert()
+	if !reflect.DeepEqual(inverted.Changed, backward.Changed) {
+		t.Errorf("反转后的Changed为%v，预期%v", inverted.Changed, backward.Changed)
+	}
+	// 反转不改变差异记录的顺序，新增和移除的路径按集合比较
+	sort.Strings(inverted.Removed)
+	sort.Strings(backward.Removed)
+	if !reflect.DeepEqual(inverted.Added, backward.Added) || !reflect.DeepEqual(inverted.Removed, backward.Removed) {
+		t.Errorf("反转后新增%v、移除%v，预期新增%v、移除%v", inverted.Added, inverted.Removed, backward.Added, backward.Removed)
+	}
+	if len(inverted.Changes) != len(backward.Changes) {
+		t.Fatalf("反转后有%d条差异记录，预期%d条", len(inverted.Changes), len(backward.Changes))
+	}
+	for _, c := range backward.Changes {
+		if actual, ok := findChange(inverted.Changes, c.Path); !ok || !reflect.DeepEqual(actual, c) {
+			t.Errorf("路径%s反转后的差异记录为%+v，预期%+v", c.Path, actual, c)
+		}
+	}
+
+	// 反转两次得到原结果
+	if twice := inverted.Invert(); !reflect.DeepEqual(twice.Changes, forward.Changes) {
+		t.Errorf("反转两次后的差异记录为%+v，预期%+v", twice.Changes, forward.Changes)
+	}
+}
+
+// TestJSONDiffResult_InvertMoves 测试反转后移动的来源与目标互换
+func TestJSONDiffResult_InvertMoves(t *testing.T) {
+	service := NewJSONDiffService(WithArrayDiffMode(ArrayDiffLCS), WithMoveDetection())
+	diff, err := service.CompareJSON(`{"a":["x","y","z"]}`, `{"a":["y","z","x"]}`)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+
+	inverted := diff.Invert()
+	if len(inverted.Moved) != 1 || !containsMove(inverted.Moved, "a[2]", "a[0]") {
+		t.Errorf("反转后的移动为%v，预期a[2]移动到a[0]", inverted.Moved)
+	}
+	if c := inverted.Changes[0]; c.Kind != ChangeMoved || c.From != "a[2]" || c.Path != "a[0]" {
+		t.Errorf("反转后的移动记录为%+v", c)
+	}
+}
+
+// TestJSONPatch_InvertRoundTrip 测试先应用补丁再应用逆补丁得到原文档
+func TestJSONPatch_InvertRoundTrip(t *testing.T) {
+	pairs := [][2]string{
+		{`{"name":"Alice","age":30,"address":{"city":"New York"},"hobbies":["reading","swimming"]}`,
+			`{"name":"Alice","age":31,"address":{"city":"Boston","zip":"02108"},"hobbies":["reading","cycling"],"email":"alice@example.com"}`},
+		{`{"a":[1,2,3,4,5,6]}`, `{"a":[0,2,7,4,1,6,8,3]}`},
+		{`{"a":[{"id":1,"v":[1,2]},{"id":2,"v":[3]},{"id":3}]}`, `{"a":[{"id":3,"x":1},{"id":4},{"id":1,"v":[2,1]}]}`},
+		{`{"a/b":{"~":[null,true]}}`, `{"a/b":{"~":[true,null,false]},"":""}`},
+		{`[1,{"a":2}]`, `{"a":2}`},
+		{`{"tpl":{"k":[1,2]},"list":[{"k":[1,2]}]}`, `{"tpl":{"k":[1,2]},"list":[],"copy":{"k":[1,2]}}`},
+	}
+	optionSets := map[string][]Option{
+		"按下标":   {WithPatchTests()},
+		"LCS":   {WithArrayDiffMode(ArrayDiffLCS), WithPatchTests()},
+		"按标识字段": {WithArrayKey("a[*]", "id"), WithPatchTests()},
+	}
+
+	for name, opts := range optionSets {
+		service := NewJSONDiffService(opts...)
+		for _, pair := range pairs {
+			patch, err := service.CreatePatch(pair[0], pair[1])
+			if err != nil {
+				t.Fatalf("[%s] 生成补丁时出错: %v", name, err)
+			}
+			inverse, err := patch.Invert()
+			if err != nil {
+				t.Fatalf("[%s] 补丁%s求逆时出错: %v", name, patchString(t, patch), err)
+			}
+
+			forward, err := service.ApplyPatch(pair[0], patchString(t, patch))
+			if err != nil {
+				t.Fatalf("[%s] 应用补丁时出错: %v", name, err)
+			}
+			restored, err := service.ApplyPatch(forward, patchString(t, inverse))
+			if err != nil {
+				t.Errorf("[%s] 应用逆补丁%s时出错: %v", name, patchString(t, inverse), err)
+				continue
+			}
+			assertSameJSON(t, restored, pair[0])
+		}
+	}
+}
+
+// TestJSONPatch_InvertTwice 测试逆补丁可以再次求逆
+func TestJSONPatch_InvertTwice(t *testing.T) {
+	service := NewJSONDiffService(WithArrayDiffMode(ArrayDiffLCS), WithPatchTests())
+	json1, json2 := `{"a":[1,2,3],"b":{"c":null}}`, `{"a":[3,1,4],"b":{"d":true}}`
+	patch, err := service.CreatePatch(json1, json2)
+	if err != nil {
+		t.Fatalf("生成补丁时出错: %v", err)
+	}
+	inverse, err := patch.Invert()
+	if err != nil {
+		t.Fatalf("补丁求逆时出错: %v", err)
+	}
+	twice, err := inverse.Invert()
+	if err != nil {
+		t.Fatalf("逆补丁求逆时出错: %v", err)
+	}
+
+	result, err := service.ApplyPatch(json1, patchString(t, twice))
+	if err != nil {
+		t.Fatalf("应用补丁时出错: %v", err)
+	}
+	assertSameJSON(t, result, json2)
+}
+
+// TestJSONPatch_InvertWithoutTests 测试缺少 test 操作时无法求逆
+func TestJSONPatch_InvertWithoutTests(t *testing.T) {
+	patch := JSONPatch{
+		{Op: PatchAdd, Path: "/a", Value: 1.0},
+		{Op: PatchTest, Path: "/b", Value: 2.0},
+		{Op: PatchReplace, Path: "/c", Value: 3.0},
+	}
+	_, err := patch.Invert()
+	var patchErr *PatchError
+	if !errors.As(err, &patchErr) {
+		t.Fatalf("预期返回*PatchError，但实际为%v", err)
+	}
+	if patchErr.Index != 2 || patchErr.Path != "/c" {
+		t.Errorf("失败操作为%d（%s），预期2（/c）", patchErr.Index, patchErr.Path)
+	}
+}
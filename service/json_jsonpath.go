@@ -0,0 +1,708 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonPath 表示解析后的 JSONPath 表达式，例如 $.items[?(@.deleted==true)].price
+type jsonPath struct {
+	expr  string
+	steps []jsonPathStep
+}
+
+// jsonPathStep 表示 JSONPath 中的一步，包含一个或多个并列的选择器，例如 ['a','b']
+type jsonPathStep struct {
+	descendant bool // 是否为 .. 递归下降，选择器作用于当前节点及其所有后代
+	selectors  []jsonPathSelector
+}
+
+// selectorKind 表示选择器的种类
+type selectorKind int
+
+const (
+	selectName     selectorKind = iota // 对象成员 .name 或 ['name']
+	selectWildcard                     // 所有成员或元素 .* 或 [*]
+	selectIndex                        // 数组下标 [n]，负数从末尾开始计算
+	selectSlice                        // 数组切片 [start:end:step]
+	selectFilter                       // 过滤器 [?(...)]
+)
+
+// jsonPathSelector 表示一个选择器
+type jsonPathSelector struct {
+	kind   selectorKind
+	name   string
+	index  int
+	slice  jsonPathSlice
+	filter filterExpr
+}
+
+// jsonPathSlice 表示数组切片，省略的起止位置按步长的方向取默认值
+type jsonPathSlice struct {
+	start, end, step int
+	hasStart, hasEnd bool
+}
+
+// jsonPathNode 表示被选中的值以及它在文档中的路径
+type jsonPathNode struct {
+	path  string
+	value interface{}
+}
+
+// isJSONPath 判断路径模式是否为 JSONPath 表达式：$ 本身或以 $. 或 $[ 开头，
+// 因此 $schema 这样以 $ 开头的键仍按普通路径模式处理
+func isJSONPath(pattern string) bool {
+	return pattern == "$" || strings.HasPrefix(pattern, "$.") || strings.HasPrefix(pattern, "$[")
+}
+
+// parseJSONPath 解析 JSONPath 表达式，支持 .name、['name']、*、..、[n]、[start:end:step]、
+// 并列的选择器 [a,b] 以及过滤器 [?(...)]；过滤器中可以使用 @ 和 $ 开头的查询、字符串、数字、
+// true、false、null，比较运算符 == != < <= > >=，逻辑运算符 && || ! 和括号，单独的查询表示存在性检查
+func parseJSONPath(expr string) (*jsonPath, error) {
+	p := &jsonPathParser{expr: expr}
+	if !p.consume("$") {
+		return nil, p.errorf("表达式必须以 $ 开头")
+	}
+	steps, err := p.parseSteps()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(expr) {
+		return nil, p.errorf("无法识别的字符 %q", expr[p.pos])
+	}
+	return &jsonPath{expr: expr, steps: steps}, nil
+}
+
+// selectNodes 对文档求值，返回所有被选中的节点，路径按 f 的格式构建
+func (p *jsonPath) selectNodes(root interface{}, f PathFormat) []jsonPathNode {
+	e := &jsonPathEval{format: f, root: root}
+	return e.run(p.steps, jsonPathNode{value: root})
+}
+
+// jsonPathParser 是 JSONPath 表达式的递归下降解析器
+type jsonPathParser struct {
+	expr string
+	pos  int
+}
+
+// errorf 返回带有当前位置的解析错误
+func (p *jsonPathParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("无效的JSONPath %q: 位置 %d %s", p.expr, p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek 返回当前字符，已到末尾时返回 0
+func (p *jsonPathParser) peek() byte {
+	if p.pos < len(p.expr) {
+		return p.expr[p.pos]
+	}
+	return 0
+}
+
+// consume 当前位置以 s 开头时跳过 s 并返回 true
+func (p *jsonPathParser) consume(s string) bool {
+	if strings.HasPrefix(p.expr[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// skipSpace 跳过空白字符
+func (p *jsonPathParser) skipSpace() {
+	for p.pos < len(p.expr) && strings.IndexByte(" \t\n\r", p.expr[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// parseSteps 解析 $ 或 @ 之后的各步，遇到不属于路径的字符时停止
+func (p *jsonPathParser) parseSteps() ([]jsonPathStep, error) {
+	var steps []jsonPathStep
+	for {
+		var step jsonPathStep
+		var err error
+		switch {
+		case p.consume(".."):
+			step.descendant = true
+			if p.peek() == '[' {
+				step.selectors, err = p.parseBracket()
+			} else {
+				step.selectors, err = p.parseDotSelector()
+			}
+		case p.consume("."):
+			step.selectors, err = p.parseDotSelector()
+		case p.peek() == '[':
+			step.selectors, err = p.parseBracket()
+		default:
+			return steps, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+}
+
+// parseDotSelector 解析 . 之后的成员名或 *
+func (p *jsonPathParser) parseDotSelector() ([]jsonPathSelector, error) {
+	if p.consume("*") {
+		return []jsonPathSelector{{kind: selectWildcard}}, nil
+	}
+	start := p.pos
+	for p.pos < len(p.expr) && isNameChar(p.expr[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("缺少成员名")
+	}
+	return []jsonPathSelector{{kind: selectName, name: p.expr[start:p.pos]}}, nil
+}
+
+// isNameChar 判断字符能否出现在 . 之后的成员名中，非 ASCII 字符都可以出现
+func isNameChar(c byte) bool {
+	return c == '_' || c == '-' || c >= 0x80 ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// parseBracket 解析方括号中以逗号分隔的选择器
+func (p *jsonPathParser) parseBracket() ([]jsonPathSelector, error) {
+	p.pos++ // [
+	var selectors []jsonPathSelector
+	for {
+		p.skipSpace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipSpace()
+		if p.consume("]") {
+			return selectors, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("缺少 ]")
+		}
+	}
+}
+
+// parseSelector 解析方括号中的单个选择器
+func (p *jsonPathParser) parseSelector() (jsonPathSelector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		name, err := p.parseString()
+		return jsonPathSelector{kind: selectName, name: name}, err
+	case c == '*':
+		p.pos++
+		return jsonPathSelector{kind: selectWildcard}, nil
+	case c == '?':
+		p.pos++
+		filter, err := p.parseOr()
+		return jsonPathSelector{kind: selectFilter, filter: filter}, err
+	case c == '-' || c == ':' || ('0' <= c && c <= '9'):
+		return p.parseIndexOrSlice()
+	}
+	return jsonPathSelector{}, p.errorf("无效的选择器")
+}
+
+// parseIndexOrSlice 解析数组下标或切片
+func (p *jsonPathParser) parseIndexOrSlice() (jsonPathSelector, error) {
+	var bounds [3]int
+	var present [3]bool
+	colons := 0
+	for {
+		p.skipSpace()
+		if c := p.peek(); c == '-' || ('0' <= c && c <= '9') {
+			n, err := p.parseInt()
+			if err != nil {
+				return jsonPathSelector{}, err
+			}
+			bounds[colons], present[colons] = n, true
+			p.skipSpace()
+		}
+		if colons == 2 || !p.consume(":") {
+			break
+		}
+		colons++
+	}
+
+	if colons == 0 {
+		if !present[0] {
+			return jsonPathSelector{}, p.errorf("缺少数组下标")
+		}
+		return jsonPathSelector{kind: selectIndex, index: bounds[0]}, nil
+	}
+	slice := jsonPathSlice{
+		start:    bounds[0],
+		end:      bounds[1],
+		step:     1,
+		hasStart: present[0],
+		hasEnd:   present[1],
+	}
+	if present[2] {
+		slice.step = bounds[2]
+	}
+	return jsonPathSelector{kind: selectSlice, slice: slice}, nil
+}
+
+// parseInt 解析可能带有负号的整数
+func (p *jsonPathParser) parseInt() (int, error) {
+	start := p.pos
+	p.consume("-")
+	for p.pos < len(p.expr) && '0' <= p.expr[p.pos] && p.expr[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.expr[start:p.pos])
+	if err != nil {
+		p.pos = start
+		return 0, p.errorf("无效的整数")
+	}
+	return n, nil
+}
+
+// parseString 解析单引号或双引号括起的字符串，支持JSON字符串的转义序列
+func (p *jsonPathParser) parseString() (string, error) {
+	quote := p.expr[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.expr) {
+		c := p.expr[p.pos]
+		if c == quote {
+			p.pos++
+			return b.String(), nil
+		}
+		if c != '\\' {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+		if p.pos+1 >= len(p.expr) {
+			break
+		}
+		switch esc := p.expr[p.pos+1]; esc {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			if p.pos+6 > len(p.expr) {
+				return "", p.errorf("无效的转义序列")
+			}
+			r, err := strconv.ParseUint(p.expr[p.pos+2:p.pos+6], 16, 32)
+			if err != nil {
+				return "", p.errorf("无效的转义序列")
+			}
+			b.WriteRune(rune(r))
+			p.pos += 4
+		default:
+			b.WriteByte(esc)
+		}
+		p.pos += 2
+	}
+	return "", p.errorf("字符串缺少结束引号")
+}
+
+// parseOr 解析以 || 连接的条件
+func (p *jsonPathParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("||") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = filterOr{left: left, right: right}
+	}
+}
+
+// parseAnd 解析以 && 连接的条件
+func (p *jsonPathParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if !p.consume("&&") {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = filterAnd{left: left, right: right}
+	}
+}
+
+// parseUnary 解析取反、括号或比较
+func (p *jsonPathParser) parseUnary() (filterExpr, error) {
+	p.skipSpace()
+	if p.consume("!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{expr: expr}, nil
+	}
+	if p.consume("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("缺少 )")
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+// compareOps 比较运算符，较长的运算符排在前面
+var compareOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseComparison 解析比较或存在性检查
+func (p *jsonPathParser) parseComparison() (filterExpr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	op := ""
+	for _, candidate := range compareOps {
+		if p.consume(candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		query, ok := left.(filterQuery)
+		if !ok {
+			return nil, p.errorf("字面量不能单独作为条件")
+		}
+		return filterExists{query: query}, nil
+	}
+
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for _, operand := range []filterOperand{left, right} {
+		if query, ok := operand.(filterQuery); ok && !query.singular() {
+			return nil, p.errorf("比较中的查询必须只选择单个值")
+		}
+	}
+	return filterCompare{op: op, left: left, right: right}, nil
+}
+
+// parseOperand 解析比较的一侧：查询或字面量
+func (p *jsonPathParser) parseOperand() (filterOperand, error) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		p.pos++
+		steps, err := p.parseSteps()
+		return filterQuery{relative: c == '@', steps: steps}, err
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		return filterLiteral{v: s}, err
+	case c == '-' || ('0' <= c && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.expr) && strings.IndexByte("0123456789+-.eE", p.expr[p.pos]) >= 0 {
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.expr[start:p.pos], 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("无效的数字")
+		}
+		return filterLiteral{v: f}, nil
+	}
+	for word, value := range map[string]interface{}{"true": true, "false": false, "null": nil} {
+		if p.consume(word) {
+			if isNameChar(p.peek()) {
+				p.pos -= len(word)
+				break
+			}
+			return filterLiteral{v: value}, nil
+		}
+	}
+	return nil, p.errorf("无效的操作数")
+}
+
+// jsonPathEval 保存一次求值所需的路径格式和根节点
+type jsonPathEval struct {
+	format PathFormat
+	root   interface{}
+}
+
+// run 从 start 开始依次执行各步，返回最后选中的节点
+func (e *jsonPathEval) run(steps []jsonPathStep, start jsonPathNode) []jsonPathNode {
+	nodes := []jsonPathNode{start}
+	for _, step := range steps {
+		var next []jsonPathNode
+		for _, n := range nodes {
+			next = e.applyStep(step, n, next)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// applyStep 对节点执行一步，递归下降时同样作用于所有后代
+func (e *jsonPathEval) applyStep(step jsonPathStep, n jsonPathNode, out []jsonPathNode) []jsonPathNode {
+	for _, sel := range step.selectors {
+		out = e.applySelector(sel, n, out)
+	}
+	if step.descendant {
+		for _, child := range e.children(n) {
+			out = e.applyStep(step, child, out)
+		}
+	}
+	return out
+}
+
+// applySelector 对节点执行单个选择器，把选中的子节点追加到 out
+func (e *jsonPathEval) applySelector(sel jsonPathSelector, n jsonPathNode, out []jsonPathNode) []jsonPathNode {
+	switch sel.kind {
+	case selectName:
+		if m, ok := n.value.(map[string]interface{}); ok {
+			if v, exists := m[sel.name]; exists {
+				out = append(out, jsonPathNode{path: e.format.key(n.path, sel.name), value: v})
+			}
+		}
+	case selectWildcard:
+		out = append(out, e.children(n)...)
+	case selectIndex:
+		if a, ok := n.value.([]interface{}); ok {
+			i := sel.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				out = append(out, jsonPathNode{path: e.format.index(n.path, i), value: a[i]})
+			}
+		}
+	case selectSlice:
+		if a, ok := n.value.([]interface{}); ok {
+			for _, i := range sel.slice.indices(len(a)) {
+				out = append(out, jsonPathNode{path: e.format.index(n.path, i), value: a[i]})
+			}
+		}
+	case selectFilter:
+		for _, child := range e.children(n) {
+			if sel.filter.test(e, child.value) {
+				out = append(out, child)
+			}
+		}
+	}
+	return out
+}
+
+// children 返回对象的所有成员（按键排序）或数组的所有元素
+func (e *jsonPathEval) children(n jsonPathNode) []jsonPathNode {
+	var out []jsonPathNode
+	switch t := n.value.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(t) {
+			out = append(out, jsonPathNode{path: e.format.key(n.path, k), value: t[k]})
+		}
+	case []interface{}:
+		for i, v := range t {
+			out = append(out, jsonPathNode{path: e.format.index(n.path, i), value: v})
+		}
+	}
+	return out
+}
+
+// indices 返回切片在长度为 n 的数组中选中的下标，规则与 RFC 9535 相同
+func (s jsonPathSlice) indices(n int) []int {
+	if s.step == 0 {
+		return nil
+	}
+	normalize := func(i int) int {
+		if i < 0 {
+			return i + n
+		}
+		return i
+	}
+	clamp := func(i, lo, hi int) int {
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+
+	var out []int
+	if s.step > 0 {
+		start, end := 0, n
+		if s.hasStart {
+			start = normalize(s.start)
+		}
+		if s.hasEnd {
+			end = normalize(s.end)
+		}
+		for i := clamp(start, 0, n); i < clamp(end, 0, n); i += s.step {
+			out = append(out, i)
+		}
+		return out
+	}
+	start, end := n-1, -1
+	if s.hasStart {
+		start = clamp(normalize(s.start), -1, n-1)
+	}
+	if s.hasEnd {
+		end = clamp(normalize(s.end), -1, n-1)
+	}
+	for i := start; i > end; i += s.step {
+		out = append(out, i)
+	}
+	return out
+}
+
+// filterExpr 表示过滤器中的条件，current 为 @ 对应的值
+type filterExpr interface {
+	test(e *jsonPathEval, current interface{}) bool
+}
+
+// filterOperand 表示比较的一侧，值不存在时 ok 为 false
+type filterOperand interface {
+	value(e *jsonPathEval, current interface{}) (v interface{}, ok bool)
+}
+
+type filterOr struct{ left, right filterExpr }
+
+func (f filterOr) test(e *jsonPathEval, current interface{}) bool {
+	return f.left.test(e, current) || f.right.test(e, current)
+}
+
+type filterAnd struct{ left, right filterExpr }
+
+func (f filterAnd) test(e *jsonPathEval, current interface{}) bool {
+	return f.left.test(e, current) && f.right.test(e, current)
+}
+
+type filterNot struct{ expr filterExpr }
+
+func (f filterNot) test(e *jsonPathEval, current interface{}) bool {
+	return !f.expr.test(e, current)
+}
+
+// filterExists 检查查询是否选中了至少一个值
+type filterExists struct{ query filterQuery }
+
+func (f filterExists) test(e *jsonPathEval, current interface{}) bool {
+	return len(f.query.nodes(e, current)) > 0
+}
+
+// filterCompare 比较两个值：不存在的值只与不存在的值相等，数字按数值比较，
+// 字符串按字典序比较大小，其他类型只能比较是否相等
+type filterCompare struct {
+	op          string
+	left, right filterOperand
+}
+
+func (f filterCompare) test(e *jsonPathEval, current interface{}) bool {
+	a, okA := f.left.value(e, current)
+	b, okB := f.right.value(e, current)
+	switch f.op {
+	case "==":
+		return filterEqual(a, okA, b, okB)
+	case "!=":
+		return !filterEqual(a, okA, b, okB)
+	case "<":
+		return filterLess(a, okA, b, okB)
+	case "<=":
+		return filterLess(a, okA, b, okB) || filterEqual(a, okA, b, okB)
+	case ">":
+		return filterLess(b, okB, a, okA)
+	case ">=":
+		return filterLess(b, okB, a, okA) || filterEqual(a, okA, b, okB)
+	}
+	return false
+}
+
+// filterEqual 判断过滤器中的两个值是否相等
+func filterEqual(a interface{}, okA bool, b interface{}, okB bool) bool {
+	if !okA || !okB {
+		return okA == okB
+	}
+	if x, ok := numberValue(a); ok {
+		y, ok := numberValue(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// filterLess 判断过滤器中的 a 是否小于 b，只有两个数字或两个字符串可以比较大小
+func filterLess(a interface{}, okA bool, b interface{}, okB bool) bool {
+	if !okA || !okB {
+		return false
+	}
+	if x, ok := numberValue(a); ok {
+		y, ok := numberValue(b)
+		return ok && x < y
+	}
+	s1, ok1 := a.(string)
+	s2, ok2 := b.(string)
+	return ok1 && ok2 && s1 < s2
+}
+
+// filterLiteral 表示过滤器中的字面量
+type filterLiteral struct{ v interface{} }
+
+func (f filterLiteral) value(e *jsonPathEval, current interface{}) (interface{}, bool) {
+	return f.v, true
+}
+
+// filterQuery 表示过滤器中以 @（当前值）或 $（根节点）开头的查询
+type filterQuery struct {
+	relative bool
+	steps    []jsonPathStep
+}
+
+// nodes 返回查询选中的所有节点
+func (f filterQuery) nodes(e *jsonPathEval, current interface{}) []jsonPathNode {
+	start := e.root
+	if f.relative {
+		start = current
+	}
+	return e.run(f.steps, jsonPathNode{value: start})
+}
+
+// value 返回查询选中的唯一值，没有选中任何值时 ok 为 false
+func (f filterQuery) value(e *jsonPathEval, current interface{}) (interface{}, bool) {
+	nodes := f.nodes(e, current)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0].value, true
+}
+
+// singular 判断查询是否最多只能选中一个值：每一步都只有一个成员名或数组下标
+func (f filterQuery) singular() bool {
+	for _, step := range f.steps {
+		if step.descendant || len(step.selectors) != 1 {
+			return false
+		}
+		if kind := step.selectors[0].kind; kind != selectName && kind != selectIndex {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// jsonPathTestDoc 是 JSONPath 测试使用的文档
+const jsonPathTestDoc = `{
+	"users": [
+		{"name": "admin", "role": "system", "age": 40},
+		{"name": "alice", "role": "user", "age": 30, "email": "a@example.com"},
+		{"name": "bob", "role": "user", "age": 25}
+	],
+	"items": [
+		{"id": 1, "price": 10, "deleted": true},
+		{"id": 2, "price": 20, "deleted": false},
+		{"id": 3, "price": 30}
+	],
+	"meta": {"limit": 25, "a.b": {"price": 1}}
+}`
+
+// TestJSONPath_Select 测试 JSONPath 表达式选中的路径
+func TestJSONPath_Select(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(jsonPathTestDoc), &doc); err != nil {
+		t.Fatalf("解析测试文档失败: %v", err)
+	}
+
+	testCases := []struct {
+		expr     string
+		expected []string
+	}{
+		{"$", []string{""}},
+		{"$.users[0].name", []string{"users[0].name"}},
+		{"$['users'][-1]['name']", []string{"users[2].name"}},
+		{"$.users[*].role", []string{"users[0].role", "users[1].role", "users[2].role"}},
+		{"$.users[0,2].age", []string{"users[0].age", "users[2].age"}},
+		{"$.items[1:].id", []string{"items[1].id", "items[2].id"}},
+		{"$.items[::-2].id", []string{"items[0].id", "items[2].id"}},
+		{"$..price", []string{"items[0].price", "items[1].price", "items[2].price", `meta["a.b"].price`}},
+		{"$.meta.*", []string{"meta.limit", `meta["a.b"]`}},
+		{"$.users[?(@.role=='system')]", []string{"users[0]"}},
+		{"$.users[?(@.role == 'user' && @.age < 30)].name", []string{"users[2].name"}},
+		{"$.users[?(@.email)]", []string{"users[1]"}},
+		{"$.users[?(!@.email)].name", []string{"users[0].name", "users[2].name"}},
+		{"$.users[?(@.age >= 30 || @.name == \"bob\")].name", []string{"users[0].name", "users[1].name", "users[2].name"}},
+		{"$.users[?(@.age == $.meta.limit)].name", []string{"users[2].name"}},
+		{"$.items[?(@.deleted==true)].price", []string{"items[0].price"}},
+		{"$.items[?(@.deleted!=true)].id", []string{"items[1].id", "items[2].id"}},
+		{"$.items[?(@.deleted==null)].id", nil},
+		{"$.items[?(@.price > 10 && (@.id == 2 || @.id == 3))].id", []string{"items[1].id", "items[2].id"}},
+		{"$.missing[*]", nil},
+	}
+
+	for _, tc := range testCases {
+		p, err := parseJSONPath(tc.expr)
+		if err != nil {
+			t.Errorf("解析%q时出错: %v", tc.expr, err)
+			continue
+		}
+		var paths []string
+		for _, n := range p.selectNodes(doc, PathDotted) {
+			paths = append(paths, n.path)
+		}
+		sort.Strings(paths)
+		if !reflect.DeepEqual(paths, tc.expected) {
+			t.Errorf("%s 选中%v，预期%v", tc.expr, paths, tc.expected)
+		}
+	}
+}
+
+// TestJSONPath_PointerFormat 测试按 JSON Pointer 格式构建选中的路径
+func TestJSONPath_PointerFormat(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(`{"a/b":[{"x":1},{"x":2}]}`), &doc); err != nil {
+		t.Fatalf("解析测试文档失败: %v", err)
+	}
+	p, err := parseJSONPath("$['a/b'][?(@.x > 1)].x")
+	if err != nil {
+		t.Fatalf("解析JSONPath时出错: %v", err)
+	}
+	nodes := p.selectNodes(doc, PathPointer)
+	if len(nodes) != 1 || nodes[0].path != "/a~1b/1/x" {
+		t.Errorf("选中的节点为%+v，预期/a~1b/1/x", nodes)
+	}
+}
+
+// TestJSONPath_InvalidExpressions 测试无效的 JSONPath 表达式
+func TestJSONPath_InvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"users",
+		"$.",
+		"$[",
+		"$['a'",
+		"$[?(@.a == )]",
+		"$[?(@.a == 1]",
+		"$[?('a')]",
+		"$[?(@.* == 1)]",
+		"$[?(@..a == 1)]",
+		"$.a b",
+		"$[abc]",
+	} {
+		if _, err := parseJSONPath(expr); err == nil {
+			t.Errorf("预期解析%q时出错，但实际没有", expr)
+		}
+	}
+}
+
+// TestCompareJSONWithIgnore_JSONPath 测试忽略路径中混合使用 JSONPath 和普通路径模式
+func TestCompareJSONWithIgnore_JSONPath(t *testing.T) {
+	service := NewJSONDiffService()
+	json1 := `{"$schema":"v1","users":[{"role":"system","token":"a"},{"role":"user","token":"b"}],"items":[{"deleted":true,"price":1},{"price":2}],"ts":1}`
+	json2 := `{"$schema":"v2","users":[{"role":"system","token":"c"},{"role":"user","token":"d"}],"items":[{"deleted":true,"price":5},{"price":6}],"ts":2}`
+
+	diff, err := service.CompareJSONWithIgnore(json1, json2, []string{
+		"$.users[?(@.role=='system')]",
+		"$.items[?(@.deleted==true)].price",
+		"ts",
+	})
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	expected := []string{"$schema", "users[1].token", "items[1].price"}
+	if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("变更路径为%v，预期%v", paths, expected)
+	}
+
+	// 只在其中一个JSON中满足条件的值同样被忽略
+	diff, err = service.CompareJSONWithIgnore(`{"a":[{"x":1,"skip":true}]}`, `{"a":[{"x":2}]}`, []string{"$.a[?(@.skip)]"})
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Errorf("预期没有差异，实际为%+v", diff.Changes)
+	}
+
+	if _, err := service.CompareJSONWithIgnore(`{}`, `{}`, []string{"$.a[?(@.b ==)]"}); err == nil {
+		t.Errorf("预期无效的JSONPath返回错误，但实际没有")
+	}
+}
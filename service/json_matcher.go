@@ -0,0 +1,277 @@
+package service
+
+// maxCachedStates 匹配器最多缓存的路径状态数，超过后清空缓存重新开始
+const maxCachedStates = 1 << 12
+
+// pathMatcher 把一组路径模式编译为按路径段推进的非确定有限自动机，每次比较只编译一次。
+// 遍历时由父路径的状态推进一段得到子路径的状态，不必为每个节点重新解析模式和路径；
+// 状态为空时该路径的整棵子树都不可能匹配，推进的代价为零
+type pathMatcher struct {
+	format   PathFormat
+	rules    int                   // 规则的数量，JSONPath 表达式没有选中任何路径时同样计数
+	patterns [][]pathSegment       // 编译后的模式
+	literals map[string]bool       // 无法解析的模式，只做精确匹配
+	states   map[string]matchState // 最近访问的路径的状态
+}
+
+// matchState 表示自动机在某个路径上的状态
+type matchState struct {
+	positions []matchPos // 尚未匹配完的模式及其下一个待匹配的模式段
+	matched   bool       // 有模式完整匹配该路径
+	inherited bool       // 有模式匹配该路径的某个祖先
+}
+
+// matchPos 表示某个模式中下一个待匹配的模式段
+type matchPos struct {
+	pattern int
+	index   int
+}
+
+// newPathMatcher 创建指定路径格式的空匹配器
+func newPathMatcher(f PathFormat) *pathMatcher {
+	return &pathMatcher{format: f}
+}
+
+// compilePathRules 把路径模式编译为匹配器，JSONPath 表达式对每个文档分别求值，
+// 在任一文档中被选中的具体路径都加入匹配器
+func (c *diffConfig) compilePathRules(patterns []string, docs ...interface{}) (*pathMatcher, error) {
+	m := newPathMatcher(c.pathFormat)
+	for _, pattern := range patterns {
+		if !isJSONPath(pattern) {
+			m.addPattern(pattern)
+			continue
+		}
+		p, err := parseJSONPath(pattern)
+		if err != nil {
+			return nil, err
+		}
+		m.rules++
+		for _, doc := range docs {
+			for _, n := range p.selectNodes(doc, c.pathFormat) {
+				m.addPath(n.path)
+			}
+		}
+	}
+	return m, nil
+}
+
+// addPattern 加入一个路径模式，模式无法解析时只做精确匹配
+func (m *pathMatcher) addPattern(pattern string) {
+	m.rules++
+	m.states = nil
+	segs, err := m.format.segments(pattern)
+	if err != nil {
+		if m.literals == nil {
+			m.literals = make(map[string]bool)
+		}
+		m.literals[pattern] = true
+		return
+	}
+	m.patterns = append(m.patterns, segs)
+}
+
+// addPath 加入一个具体路径，路径中的 * 按字面匹配
+func (m *pathMatcher) addPath(path string) {
+	m.states = nil
+	segs, err := m.format.segments(path)
+	if err != nil {
+		return
+	}
+	for i := range segs {
+		segs[i].wildcard, segs[i].glob, segs[i].recursive = false, false, false
+	}
+	m.patterns = append(m.patterns, segs)
+}
+
+// empty 判断匹配器是否没有任何规则
+func (m *pathMatcher) empty() bool {
+	return m == nil || m.rules == 0
+}
+
+// matches 检查路径是否匹配任一规则
+func (m *pathMatcher) matches(path string) bool {
+	if m.empty() {
+		return false
+	}
+	return m.state(path).matched
+}
+
+// state 返回路径上的状态，缓存中没有时从根开始逐段计算
+func (m *pathMatcher) state(path string) matchState {
+	if s, ok := m.states[path]; ok {
+		return s
+	}
+	s := m.rootState()
+	if path != "" {
+		segs, err := m.format.segments(path)
+		if err != nil {
+			return matchState{matched: m.literals[path]}
+		}
+		for _, seg := range segs {
+			s = m.step(s, seg)
+		}
+		s.matched = s.matched || m.literals[path]
+	}
+	m.cache(path, s)
+	return s
+}
+
+// descend 由缓存中父路径的状态推进一段得到子路径的状态，父路径不在缓存中时留待需要时再计算
+func (m *pathMatcher) descend(parent, child string, seg pathSegment) {
+	if m.empty() {
+		return
+	}
+	ps, ok := m.states[parent]
+	if !ok {
+		return
+	}
+	s := m.step(ps, seg)
+	s.matched = s.matched || m.literals[child]
+	m.cache(child, s)
+}
+
+// cache 缓存路径的状态，缓存过大时先清空（保留已分配的空间）
+func (m *pathMatcher) cache(path string, s matchState) {
+	if m.states == nil {
+		m.states = make(map[string]matchState, maxCachedStates)
+	} else if len(m.states) >= maxCachedStates {
+		for k := range m.states {
+			delete(m.states, k)
+		}
+	}
+	m.states[path] = s
+}
+
+// rootState 返回根路径上的状态
+func (m *pathMatcher) rootState() matchState {
+	var s matchState
+	for p := range m.patterns {
+		s.add(m.patterns, matchPos{pattern: p})
+	}
+	s.matched = s.matched || m.literals[""]
+	return s
+}
+
+// step 把状态推进一个路径段
+func (m *pathMatcher) step(s matchState, seg pathSegment) matchState {
+	next := matchState{inherited: s.matched || s.inherited}
+	for _, pos := range s.positions {
+		p := m.patterns[pos.pattern][pos.index]
+		switch {
+		case p.recursive:
+			// ** 匹配当前路径段后仍可以继续匹配更多路径段
+			next.add(m.patterns, pos)
+		case m.format.matchSegment(seg, p):
+			next.add(m.patterns, matchPos{pattern: pos.pattern, index: pos.index + 1})
+		}
+	}
+	return next
+}
+
+// add 加入一个待匹配的位置，** 可以匹配零个路径段，因此同时加入它之后的位置
+func (s *matchState) add(patterns [][]pathSegment, pos matchPos) {
+	segs := patterns[pos.pattern]
+	for {
+		if pos.index == len(segs) {
+			s.matched = true
+			return
+		}
+		for _, existing := range s.positions {
+			if existing == pos {
+				return
+			}
+		}
+		s.positions = append(s.positions, pos)
+		if !segs[pos.index].recursive {
+			return
+		}
+		pos.index++
+	}
+}
+
+// rulePatterns 把一组按顺序生效的规则模式编译一次，查找路径最先匹配的规则时只解析一次路径，不再重新解析模式
+type rulePatterns struct {
+	format   PathFormat
+	patterns []string
+	segments [][]pathSegment // 编译后的模式，无法解析的模式为 nil，只做精确匹配
+}
+
+// newRulePatterns 编译一组规则模式
+func newRulePatterns(f PathFormat, patterns []string) *rulePatterns {
+	r := &rulePatterns{format: f, patterns: patterns, segments: make([][]pathSegment, len(patterns))}
+	for i, pattern := range patterns {
+		if segs, err := f.segments(pattern); err == nil && segs != nil {
+			r.segments[i] = segs
+		}
+	}
+	return r
+}
+
+// first 返回路径最先匹配的规则的下标，没有匹配的规则时返回 -1
+func (r *rulePatterns) first(path string) int {
+	var segs []pathSegment
+	parsed, valid := false, false
+	for i, pattern := range r.segments {
+		if pattern == nil {
+			if path == r.patterns[i] {
+				return i
+			}
+			continue
+		}
+		if !parsed {
+			var err error
+			segs, err = r.format.segments(path)
+			parsed, valid = true, err == nil
+		}
+		if valid && r.format.matchSegments(segs, pattern) {
+			return i
+		}
+	}
+	return -1
+}
+
+// matches 检查路径是否匹配任一规则
+func (r *rulePatterns) matches(path string) bool {
+	return r.first(path) >= 0
+}
+
+// compiledRules 保存按路径查找的各类规则编译后的模式，配置在每次比较前编译一次
+type compiledRules struct {
+	comparators *rulePatterns
+	tolerances  *rulePatterns
+	arrayKeys   *rulePatterns // 以数组通配符结尾的模式去掉了最后一段，直接匹配数组本身的路径
+	unordered   *rulePatterns
+}
+
+// compileRules 编译配置中按路径查找的规则
+func (c *diffConfig) compileRules() *compiledRules {
+	f := c.pathFormat
+	patterns := func(n int, pattern func(i int) string) []string {
+		ps := make([]string, n)
+		for i := range ps {
+			ps[i] = pattern(i)
+		}
+		return ps
+	}
+	r := &compiledRules{
+		comparators: newRulePatterns(f, patterns(len(c.comparators), func(i int) string { return c.comparators[i].pattern })),
+		tolerances:  newRulePatterns(f, patterns(len(c.tolerances), func(i int) string { return c.tolerances[i].pattern })),
+		arrayKeys:   newRulePatterns(f, patterns(len(c.arrayKeys), func(i int) string { return c.arrayKeys[i].pattern })),
+		unordered:   newRulePatterns(f, c.unordered),
+	}
+	// 规则可以描述数组本身，也可以用通配符描述数组元素：数组通配符匹配任意下标，去掉它后匹配数组的路径即可
+	for i, segs := range r.arrayKeys.segments {
+		if n := len(segs); n > 0 && segs[n-1].wildcard {
+			r.arrayKeys.segments[i] = segs[:n-1]
+		}
+	}
+	return r
+}
+
+// compiled 返回编译后的规则，配置未经 with 编译时临时编译
+func (c *diffConfig) compiled() *compiledRules {
+	if c.rules != nil {
+		return c.rules
+	}
+	return c.compileRules()
+}
@@ -0,0 +1,95 @@
+package service
+
+import "testing"
+
+// TestPathMatcher_MatchesLikePatterns 测试编译后的匹配器与逐个匹配模式的结果一致，
+// 无论状态由父路径推进得到还是从根开始计算
+func TestPathMatcher_MatchesLikePatterns(t *testing.T) {
+	patterns := []string{"users[*].name", "**.updatedAt", "meta.*", "x-*", "a.**.z", `["*"]`, "a..b"}
+	paths := []string{
+		"", "users", "users[0]", "users[0].name", "users.x.name", "updatedAt", "a[1].b.updatedAt",
+		"meta", "meta.k", "meta.k.j", "x-trace", "y-trace", "a", "a.z", "a.b[0].z", "a.z.q", `["*"]`, "star", "a..b",
+	}
+
+	m := newPathMatcher(PathDotted)
+	for _, pattern := range patterns {
+		m.addPattern(pattern)
+	}
+	for _, path := range paths {
+		expected := PathDotted.matchesAny(path, patterns)
+		if actual := m.matches(path); actual != expected {
+			t.Errorf("matches(%q) = %v，预期%v", path, actual, expected)
+		}
+	}
+
+	// 由父路径逐段推进得到的状态与从根计算的结果相同
+	m.states = nil
+	m.state("")
+	parent := ""
+	for _, key := range []string{"a", "b", "updatedAt"} {
+		child := PathDotted.key(parent, key)
+		m.descend(parent, child, PathDotted.keySegment(key))
+		if _, cached := m.states[child]; !cached {
+			t.Fatalf("%s的状态应该由父路径推进得到", child)
+		}
+		parent = child
+	}
+	if !m.matches("a.b.updatedAt") {
+		t.Errorf("a.b.updatedAt应该匹配**.updatedAt")
+	}
+}
+
+// TestPathMatcher_Pruning 测试不可能匹配的子树状态为空
+func TestPathMatcher_Pruning(t *testing.T) {
+	m := newPathMatcher(PathPointer)
+	m.addPattern("/users/*/token")
+	if s := m.state("/orders/0"); len(s.positions) != 0 || s.matched {
+		t.Errorf("/orders/0之下不可能匹配，状态应为空，实际为%+v", s)
+	}
+	if s := m.state("/users/0"); len(s.positions) != 1 {
+		t.Errorf("/users/0之下可能匹配，实际状态为%+v", s)
+	}
+	if !m.matches("/users/0/token") || m.matches("/users/0/token/x") {
+		t.Errorf("只有/users/0/token应该匹配")
+	}
+
+	// 状态缓存超过上限时清空后仍能得到正确结果
+	for i := 0; i < maxCachedStates+10; i++ {
+		m.descend("", PathPointer.index("", i), PathPointer.indexSegment(i))
+	}
+	if len(m.states) > maxCachedStates {
+		t.Errorf("缓存了%d个状态，超过上限%d", len(m.states), maxCachedStates)
+	}
+	if !m.matches("/users/1/token") {
+		t.Errorf("清空缓存后/users/1/token应该匹配")
+	}
+}
+
+// TestRulePatterns_FirstMatch 测试编译后的规则与逐个匹配模式的结果一致，返回最先匹配的规则
+func TestRulePatterns_FirstMatch(t *testing.T) {
+	patterns := []string{"users[*].name", "**.updatedAt", "meta.*", "x-*", "a.**.z", `["*"]`, "a..b"}
+	paths := []string{"", "users[0].name", "a[1].b.updatedAt", "meta.k", "x-trace", "a.b[0].z", "a.z.q", `["*"]`, "a..b"}
+
+	r := newRulePatterns(PathDotted, patterns)
+	for _, path := range paths {
+		expected := -1
+		for i, pattern := range patterns {
+			if PathDotted.matches(path, pattern) {
+				expected = i
+				break
+			}
+		}
+		if actual := r.first(path); actual != expected {
+			t.Errorf("first(%q) = %d，预期%d", path, actual, expected)
+		}
+	}
+
+	// 标识字段规则可以描述数组本身，也可以用通配符描述数组元素
+	c := &diffConfig{pathFormat: PathPointer, arrayKeys: []arrayKeyRule{{pattern: "/users/*", fields: []string{"id"}}, {pattern: "/orders"}}}
+	rules := c.compileRules()
+	for path, expected := range map[string]int{"/users": 0, "/users/0": -1, "/orders": 1, "": -1} {
+		if actual := rules.arrayKeys.first(path); actual != expected {
+			t.Errorf("标识字段规则first(%q) = %d，预期%d", path, actual, expected)
+		}
+	}
+}
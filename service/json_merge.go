@@ -0,0 +1,294 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ConflictStrategy 表示三方合并遇到冲突时的处理策略
+type ConflictStrategy int
+
+const (
+	// ConflictFail 存在冲突时返回 ErrMergeConflict（默认策略）
+	ConflictFail ConflictStrategy = iota
+	// ConflictOurs 冲突处采用本地（ours）的值
+	ConflictOurs
+	// ConflictTheirs 冲突处采用上游（theirs）的值
+	ConflictTheirs
+)
+
+// ErrMergeConflict 表示三方合并存在无法自动解决的冲突
+var ErrMergeConflict = errors.New("三方合并存在冲突")
+
+// MergeConflict 表示三方合并中的一处冲突，两侧对同一路径做了不同的修改
+type MergeConflict struct {
+	Path          string      `json:"path"`          // 冲突所在路径
+	Base          interface{} `json:"base"`          // 基准版本中的值，不存在时为 null
+	Ours          interface{} `json:"ours"`          // 本地版本中的值
+	Theirs        interface{} `json:"theirs"`        // 上游版本中的值
+	OursRemoved   bool        `json:"oursRemoved"`   // 本地版本是否删除了该路径
+	TheirsRemoved bool        `json:"theirsRemoved"` // 上游版本是否删除了该路径
+}
+
+// MergeResult 表示三方合并的结果
+type MergeResult struct {
+	Merged    string          `json:"merged"`    // 合并后的JSON文档，策略为 ConflictFail 且存在冲突时为空
+	Conflicts []MergeConflict `json:"conflicts"` // 合并过程中遇到的冲突
+}
+
+// Merge3 以 base 为基准合并 ours 和 theirs 两个版本：只有一侧修改的路径自动采用修改后的值，
+// 两侧修改相同时直接采用，两侧修改不同的路径按 strategy 处理并记录在 Conflicts 中。
+// 对象逐个成员合并；数组按服务配置的数组策略（按标识字段配对、忽略顺序、LCS 或按下标）对齐元素后逐个元素合并
+func (s *jsonDiffServiceImpl) Merge3(base, ours, theirs string, strategy ConflictStrategy) (MergeResult, error) {
+	var result MergeResult
+	names := []string{"基准", "本地", "上游"}
+	objs := make([]interface{}, len(names))
+	config := s.config.with(nil)
+	order := config.newKeyOrder()
+	for i, doc := range []string{base, ours, theirs} {
+		var err error
+		if objs[i], err = config.parseDocument(doc, order); err != nil {
+			return result, fmt.Errorf("解析%sJSON失败: %v", names[i], err)
+		}
+	}
+
+	m := &threeWayMerger{config: &config, format: config.pathFormat, order: order, strategy: strategy}
+	merged := m.merge("", present(objs[0]), present(objs[1]), present(objs[2]))
+	result.Conflicts = m.conflicts
+	if strategy == ConflictFail && len(m.conflicts) > 0 {
+		return result, fmt.Errorf("%w: 共 %d 处", ErrMergeConflict, len(m.conflicts))
+	}
+
+	text, err := marshalDocument(merged.value)
+	if err != nil {
+		return result, err
+	}
+	result.Merged = text
+	return result, nil
+}
+
+// mergeValue 表示某个版本中一个路径上的值，exists 为 false 表示该路径不存在
+type mergeValue struct {
+	value  interface{}
+	exists bool
+}
+
+// present 构造一个存在的值
+func present(v interface{}) mergeValue {
+	return mergeValue{value: v, exists: true}
+}
+
+// memberOf 返回对象成员在某个版本中的值，父值不是对象时成员不存在
+func memberOf(parent mergeValue, key string) mergeValue {
+	if m, ok := parent.value.(map[string]interface{}); ok && parent.exists {
+		if v, exists := m[key]; exists {
+			return present(v)
+		}
+	}
+	return mergeValue{}
+}
+
+// sameValue 判断两个版本中的值是否相同
+func sameValue(a, b mergeValue) bool {
+	return a.exists == b.exists && (!a.exists || reflect.DeepEqual(a.value, b.value))
+}
+
+// threeWayMerger 保存三方合并过程中的配置、路径格式、成员顺序、冲突策略和冲突列表
+type threeWayMerger struct {
+	config    *diffConfig
+	format    PathFormat
+	order     keyOrder
+	strategy  ConflictStrategy
+	conflicts []MergeConflict
+}
+
+// merge 合并一个路径上三个版本的值
+func (m *threeWayMerger) merge(path string, base, ours, theirs mergeValue) mergeValue {
+	switch {
+	case sameValue(ours, theirs), sameValue(base, theirs):
+		return ours
+	case sameValue(base, ours):
+		return theirs
+	}
+
+	// 两侧都修改了该路径，都是对象时逐个成员合并
+	om, oursIsObject := ours.value.(map[string]interface{})
+	tm, theirsIsObject := theirs.value.(map[string]interface{})
+	if oursIsObject && theirsIsObject {
+		merged := make(map[string]interface{})
+		for _, k := range m.unionKeys(om, tm, base.value) {
+			v := m.merge(m.format.key(path, k), memberOf(base, k), memberOf(ours, k), memberOf(theirs, k))
+			if v.exists {
+				merged[k] = v.value
+			}
+		}
+		return present(merged)
+	}
+
+	// 三个版本都是数组时对齐元素后逐个合并，两侧都改变了元素顺序并且顺序不同时整个数组视为冲突
+	ba, baseIsArray := base.value.([]interface{})
+	oa, oursIsArray := ours.value.([]interface{})
+	ta, theirsIsArray := theirs.value.([]interface{})
+	if baseIsArray && oursIsArray && theirsIsArray {
+		if merged, ok := m.mergeArrays(path, ba, oa, ta); ok {
+			return present(merged)
+		}
+	}
+
+	m.conflicts = append(m.conflicts, MergeConflict{
+		Path:          path,
+		Base:          base.value,
+		Ours:          ours.value,
+		Theirs:        theirs.value,
+		OursRemoved:   base.exists && !ours.exists,
+		TheirsRemoved: base.exists && !theirs.exists,
+	})
+	if m.strategy == ConflictTheirs {
+		return theirs
+	}
+	return ours
+}
+
+// unionKeys 返回多个对象中所有键的并集，不是对象的值会被跳过；
+// 按文档顺序时依次排列各个对象中新出现的键，否则按字典序排列
+func (m *threeWayMerger) unionKeys(objects ...interface{}) []string {
+	union := make(map[string]interface{})
+	var keys []string
+	for _, o := range objects {
+		if obj, ok := o.(map[string]interface{}); ok {
+			for _, k := range m.order.keys(obj) {
+				if _, exists := union[k]; !exists {
+					union[k] = nil
+					keys = append(keys, k)
+				}
+			}
+		}
+	}
+	if m.order == nil {
+		return sortedKeys(union)
+	}
+	return keys
+}
+
+// mergeArrays 按服务配置的数组策略把两侧数组的元素分别与基准版本配对，再逐个元素合并：
+// 与基准元素配对的元素按三方合并，一侧删除而另一侧未修改的元素被删除；
+// 只在一侧新增的元素插入到该侧中它后面最近的共有元素之前，两侧新增的相同元素
+// （按标识字段配对的数组中标识相同的元素）合并为一个。
+// 结果采用改变了元素相对顺序的一侧的顺序，两侧都改变且顺序不同时返回 false
+func (m *threeWayMerger) mergeArrays(path string, base, ours, theirs []interface{}) ([]interface{}, bool) {
+	sides := [2][]interface{}{ours, theirs}
+
+	// toBase[s][j] 为第 s 侧第 j 个元素配对的基准元素下标，fromBase[s][i] 为第 i 个基准元素在第 s 侧的下标，没有时为 -1
+	var toBase, fromBase [2][]int
+	for s, side := range sides {
+		toBase[s], fromBase[s] = unpaired(len(side)), unpaired(len(base))
+		for _, p := range m.config.arrayPairs(path, base, side, nil) {
+			fromBase[s][p[0]], toBase[s][p[1]] = p[1], p[0]
+		}
+	}
+
+	// 两侧新增的元素之间配对：按标识字段配对的数组按标识，其余数组按值
+	var added [2][]int
+	var addedValues [2][]interface{}
+	for s, side := range sides {
+		for j, b := range toBase[s] {
+			if b < 0 {
+				added[s] = append(added[s], j)
+				addedValues[s] = append(addedValues[s], side[j])
+			}
+		}
+	}
+	var insertPairs [][2]int
+	if fields := m.config.arrayKeyFields(path); fields != nil {
+		insertPairs = keyedPairs(addedValues[0], addedValues[1], fields, nil)
+	} else {
+		insertPairs = equalPairs(addedValues[0], addedValues[1], nil, nil, nil)
+	}
+	// counterpart[s][j] 为第 s 侧第 j 个元素在另一侧对应的元素下标，没有时为 -1
+	counterpart := [2][]int{unpaired(len(ours)), unpaired(len(theirs))}
+	for s := range sides {
+		for j, b := range toBase[s] {
+			if b >= 0 {
+				counterpart[s][j] = fromBase[1-s][b]
+			}
+		}
+	}
+	for _, p := range insertPairs {
+		o, t := added[0][p[0]], added[1][p[1]]
+		counterpart[0][o], counterpart[1][t] = t, o
+	}
+
+	// 选择决定结果顺序的一侧：两侧共有元素的相对顺序一致时采用本地的顺序，
+	// 否则采用改变了相对顺序的一侧，忽略顺序的数组不考虑元素顺序
+	lead := 0
+	if !m.config.compiled().unordered.matches(path) && !increasing(counterpart[0]) {
+		switch {
+		case increasing(toBase[0]):
+			lead = 1
+		case !increasing(toBase[1]):
+			return nil, false
+		}
+	}
+	other := 1 - lead
+
+	// 另一侧独有的元素按它后面最近的共有元素分组，位于所有共有元素之后的元素放在结果末尾
+	preceding := make(map[int][]int)
+	next := len(sides[lead])
+	for j := len(sides[other]) - 1; j >= 0; j-- {
+		if c := counterpart[other][j]; c >= 0 {
+			next = c
+		} else {
+			preceding[next] = append([]int{j}, preceding[next]...)
+		}
+	}
+
+	merged := []interface{}{}
+	mergeElement := func(s, j int) {
+		var values [2]mergeValue
+		values[s] = present(sides[s][j])
+		if c := counterpart[s][j]; c >= 0 {
+			values[1-s] = present(sides[1-s][c])
+		}
+		var baseValue mergeValue
+		if b := toBase[s][j]; b >= 0 {
+			baseValue = present(base[b])
+		}
+		if v := m.merge(m.format.index(path, len(merged)), baseValue, values[0], values[1]); v.exists {
+			merged = append(merged, v.value)
+		}
+	}
+	for j := 0; j <= len(sides[lead]); j++ {
+		for _, k := range preceding[j] {
+			mergeElement(other, k)
+		}
+		if j < len(sides[lead]) {
+			mergeElement(lead, j)
+		}
+	}
+	return merged, true
+}
+
+// unpaired 返回长度为 n、所有元素都为 -1 的下标切片
+func unpaired(n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = -1
+	}
+	return indexes
+}
+
+// increasing 判断切片中不为 -1 的下标是否严格递增
+func increasing(indexes []int) bool {
+	last := -1
+	for _, i := range indexes {
+		if i < 0 {
+			continue
+		}
+		if i < last {
+			return false
+		}
+		last = i
+	}
+	return true
+}
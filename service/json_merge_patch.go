@@ -0,0 +1,108 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// MergePatchResult 表示生成的 RFC 7386 JSON Merge Patch
+type MergePatchResult struct {
+	Patch    json.RawMessage `json:"patch"`    // 合并补丁文档
+	Warnings []string        `json:"warnings"` // 合并补丁无法准确表达的变化，应用补丁后这些路径与第二个JSON不同
+}
+
+// CreateMergePatch 计算把第一个JSON转换为第二个JSON的最小合并补丁。
+// 被移除的成员用 null 表示，数组只能整体替换；
+// 第二个JSON中显式的 null 成员无法用合并补丁表达，会在 Warnings 中列出对应路径
+func (s *jsonDiffServiceImpl) CreateMergePatch(json1, json2 string) (MergePatchResult, error) {
+	var result MergePatchResult
+	order := s.config.newKeyOrder()
+	obj1, obj2, err := s.config.parseJSONPair(json1, json2, order)
+	if err != nil {
+		return result, err
+	}
+
+	g := &mergePatchBuilder{format: s.config.pathFormat, order: order}
+	patch, changed := g.diff("", obj1, obj2)
+	if !changed {
+		// 空对象只对对象文档表示没有修改，其他文档应用任何补丁都会被整体替换，直接使用第二个JSON
+		if _, isObject := obj2.(map[string]interface{}); isObject {
+			patch = map[string]interface{}{}
+		} else {
+			patch = obj2
+		}
+	}
+	if result.Patch, err = json.Marshal(patch); err != nil {
+		return result, fmt.Errorf("序列化合并补丁失败: %v", err)
+	}
+	result.Warnings = g.warnings
+	return result, nil
+}
+
+// mergePatchBuilder 在遍历两个值的过程中生成合并补丁并收集警告
+type mergePatchBuilder struct {
+	format   PathFormat
+	order    keyOrder
+	warnings []string
+}
+
+// diff 返回把 v1 转换为 v2 的合并补丁，两个值相同时 changed 为 false
+func (g *mergePatchBuilder) diff(path string, v1, v2 interface{}) (patch interface{}, changed bool) {
+	m1, ok1 := v1.(map[string]interface{})
+	m2, ok2 := v2.(map[string]interface{})
+	if !ok1 || !ok2 {
+		if reflect.DeepEqual(v1, v2) {
+			return nil, false
+		}
+		if path != "" && v2 == nil {
+			g.warnNull(path)
+		}
+		g.checkNulls(path, v2)
+		return v2, true
+	}
+
+	// 和 compareValues 一样逐个比较对象成员：移除的成员记为 null，新增的成员记为新值
+	result := make(map[string]interface{})
+	for _, k := range g.order.keys(m1) {
+		fullPath := g.format.key(path, k)
+		if v, exists := m2[k]; !exists {
+			result[k] = nil
+		} else if p, changed := g.diff(fullPath, m1[k], v); changed {
+			result[k] = p
+		}
+	}
+	for _, k := range g.order.keys(m2) {
+		if _, exists := m1[k]; !exists {
+			fullPath := g.format.key(path, k)
+			if m2[k] == nil {
+				g.warnNull(fullPath)
+				continue
+			}
+			g.checkNulls(fullPath, m2[k])
+			result[k] = m2[k]
+		}
+	}
+	return result, len(result) > 0
+}
+
+// checkNulls 检查作为整体写入的值中是否含有对象成员的 null，这些成员在应用补丁时会被删除
+func (g *mergePatchBuilder) checkNulls(path string, v interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, k := range g.order.keys(m) {
+		fullPath := g.format.key(path, k)
+		if m[k] == nil {
+			g.warnNull(fullPath)
+		} else {
+			g.checkNulls(fullPath, m[k])
+		}
+	}
+}
+
+// warnNull 记录无法表示的显式 null
+func (g *mergePatchBuilder) warnNull(path string) {
+	g.warnings = append(g.warnings, fmt.Sprintf("%s: 合并补丁无法表示显式的 null 值，应用补丁后该成员会被删除", path))
+}
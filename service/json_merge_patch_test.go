@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+)
+
+// TestCreateMergePatch 测试生成最小的合并补丁
+func TestCreateMergePatch(t *testing.T) {
+	service := NewJSONDiffService()
+	testCases := []struct {
+		desc     string
+		json1    string
+		json2    string
+		expected string
+	}{
+		{"相同的JSON", `{"a":{"b":1}}`, `{"a":{"b":1}}`, `{}`},
+		{"修改和新增成员", `{"a":"b","c":{"d":1,"e":2}}`, `{"a":"z","c":{"d":1,"e":3,"f":4}}`, `{"a":"z","c":{"e":3,"f":4}}`},
+		{"移除成员", `{"a":"b","c":{"d":1,"e":2}}`, `{"c":{"d":1}}`, `{"a":null,"c":{"e":null}}`},
+		{"数组整体替换", `{"tags":["a","b"],"x":1}`, `{"tags":["a","c"],"x":1}`, `{"tags":["a","c"]}`},
+		{"对象变为数组", `{"a":{"b":1}}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"文档不是对象", `[1,2]`, `[1,3]`, `[1,3]`},
+		{"文档变为null", `{"a":1}`, `null`, `null`},
+		{"相同的数组", `[1,2]`, `[1,2]`, `[1,2]`},
+		{"相同的数字", `5`, `5`, `5`},
+		{"相同的null", `null`, `null`, `null`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := service.CreateMergePatch(tc.json1, tc.json2)
+			if err != nil {
+				t.Fatalf("生成合并补丁时出错: %v", err)
+			}
+			if string(result.Patch) != tc.expected {
+				t.Errorf("生成的合并补丁为%s，预期%s", result.Patch, tc.expected)
+			}
+			if len(result.Warnings) > 0 {
+				t.Errorf("预期没有警告，但实际为: %v", result.Warnings)
+			}
+
+			// 应用生成的合并补丁应该得到第二个JSON
+			applied, err := service.ApplyMergePatch(tc.json1, string(result.Patch))
+			if err != nil {
+				t.Fatalf("应用合并补丁时出错: %v", err)
+			}
+			assertSameJSON(t, applied, tc.json2)
+		})
+	}
+}
+
+// TestCreateMergePatch_ExplicitNulls 测试第二个JSON中显式的null无法表示时给出警告
+func TestCreateMergePatch_ExplicitNulls(t *testing.T) {
+	service := NewJSONDiffService()
+	json1 := `{"a":1,"b":{"c":null},"list":[1]}`
+	json2 := `{"a":null,"b":{"c":null},"d":null,"e":{"f":null,"g":1},"list":[null]}`
+
+	result, err := service.CreateMergePatch(json1, json2)
+	if err != nil {
+		t.Fatalf("生成合并补丁时出错: %v", err)
+	}
+	if string(result.Patch) != `{"a":null,"e":{"f":null,"g":1},"list":[null]}` {
+		t.Errorf("生成的合并补丁为%s", result.Patch)
+	}
+
+	// 数组中的null可以整体替换，b.c在两个JSON中都是null，不需要警告
+	expected := []string{
+		"a: 合并补丁无法表示显式的 null 值，应用补丁后该成员会被删除",
+		"d: 合并补丁无法表示显式的 null 值，应用补丁后该成员会被删除",
+		"e.f: 合并补丁无法表示显式的 null 值，应用补丁后该成员会被删除",
+	}
+	if len(result.Warnings) != len(expected) {
+		t.Fatalf("预期有%d条警告，但实际为: %v", len(expected), result.Warnings)
+	}
+	for i := range expected {
+		if result.Warnings[i] != expected[i] {
+			t.Errorf("第%d条警告为%q，预期%q", i, result.Warnings[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestMerge3_NonOverlapping 测试两侧修改不同路径时自动合并
+func TestMerge3_NonOverlapping(t *testing.T) {
+	service := NewJSONDiffService()
+	base := `{"name":"app","port":80,"tags":["a","b"],"db":{"host":"localhost","user":"root"},"debug":true}`
+	ours := `{"name":"app","port":8080,"tags":["a","x"],"db":{"host":"localhost","user":"admin"}}`
+	theirs := `{"name":"app2","port":80,"tags":["a","b"],"db":{"host":"db.local","user":"root","pool":5},"debug":true}`
+
+	result, err := service.Merge3(base, ours, theirs, ConflictFail)
+	if err != nil {
+		t.Fatalf("合并时出错: %v", err)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("预期没有冲突，但实际为%v", result.Conflicts)
+	}
+	assertSameJSON(t, result.Merged, `{"name":"app2","port":8080,"tags":["a","x"],"db":{"host":"db.local","user":"admin","pool":5}}`)
+}
+
+// TestMerge3_SameChange 测试两侧做了相同的修改时不算冲突
+func TestMerge3_SameChange(t *testing.T) {
+	service := NewJSONDiffService()
+	result, err := service.Merge3(`{"a":1,"b":2}`, `{"a":3,"c":[1]}`, `{"a":3,"c":[1]}`, ConflictFail)
+	if err != nil {
+		t.Fatalf("合并时出错: %v", err)
+	}
+	assertSameJSON(t, result.Merged, `{"a":3,"c":[1]}`)
+}
+
+// TestMerge3_Conflicts 测试冲突的记录和各个处理策略
+func TestMerge3_Conflicts(t *testing.T) {
+	service := NewJSONDiffService()
+	base := `{"a":1,"b":{"c":1},"list":[1,2],"keep":true}`
+	ours := `{"a":2,"list":[1,4],"keep":true,"n":"x"}`
+	theirs := `{"a":3,"b":{"c":2},"list":[1,5],"keep":true,"n":"y"}`
+
+	result, err := service.Merge3(base, ours, theirs, ConflictFail)
+	if !errors.Is(err, ErrMergeConflict) {
+		t.Fatalf("预期返回ErrMergeConflict，但实际为%v", err)
+	}
+	if result.Merged != "" {
+		t.Errorf("合并失败时不应返回文档，但实际为%s", result.Merged)
+	}
+
+	expected := map[string]MergeConflict{
+		"a":       {Path: "a", Base: 1.0, Ours: 2.0, Theirs: 3.0},
+		"b":       {Path: "b", Base: map[string]interface{}{"c": 1.0}, Theirs: map[string]interface{}{"c": 2.0}, OursRemoved: true},
+		"list[1]": {Path: "list[1]", Base: 2.0, Ours: 4.0, Theirs: 5.0},
+		"n":       {Path: "n", Ours: "x", Theirs: "y"},
+	}
+	if len(result.Conflicts) != len(expected) {
+		t.Fatalf("冲突数量为%d，预期%d: %v", len(result.Conflicts), len(expected), result.Conflicts)
+	}
+	for _, c := range result.Conflicts {
+		e, ok := expected[c.Path]
+		if !ok {
+			t.Errorf("意外的冲突: %v", c)
+			continue
+		}
+		if c.OursRemoved != e.OursRemoved || c.TheirsRemoved != e.TheirsRemoved ||
+			!sameValue(present(c.Base), present(e.Base)) || !sameValue(present(c.Ours), present(e.Ours)) || !sameValue(present(c.Theirs), present(e.Theirs)) {
+			t.Errorf("冲突为%+v，预期%+v", c, e)
+		}
+	}
+
+	result, err = service.Merge3(base, ours, theirs, ConflictOurs)
+	if err != nil {
+		t.Fatalf("合并时出错: %v", err)
+	}
+	if len(result.Conflicts) != len(expected) {
+		t.Errorf("采用本地值时也应报告冲突，但实际为%v", result.Conflicts)
+	}
+	assertSameJSON(t, result.Merged, ours)
+
+	result, err = service.Merge3(base, ours, theirs, ConflictTheirs)
+	if err != nil {
+		t.Fatalf("合并时出错: %v", err)
+	}
+	assertSameJSON(t, result.Merged, theirs)
+}
+
+// TestMerge3_ArrayElements 测试数组按下标对齐后逐个元素合并
+func TestMerge3_ArrayElements(t *testing.T) {
+	service := NewJSONDiffService()
+	base := `{"items":[{"id":1,"v":1},{"id":2,"v":1}]}`
+	ours := `{"items":[{"id":1,"v":2},{"id":2,"v":1}]}`
+	theirs := `{"items":[{"id":1,"v":3},{"id":2,"v":5}]}`
+
+	result, err := service.Merge3(base, ours, theirs, ConflictOurs)
+	if err != nil {
+		t.Fatalf("合并时出错: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "items[0].v" {
+		t.Errorf("预期只有items[0].v冲突，但实际为%v", result.Conflicts)
+	}
+	assertSameJSON(t, result.Merged, `{"items":[{"id":1,"v":2},{"id":2,"v":5}]}`)
+
+	if _, err := service.Merge3(base, `{`, theirs, ConflictOurs); err == nil {
+		t.Errorf("预期本地JSON无效时出错，但实际没有")
+	}
+}
+
+// TestMerge3_ArrayLengthChanges 测试一侧改变数组长度、另一侧修改元素时自动合并
+func TestMerge3_ArrayLengthChanges(t *testing.T) {
+	service := NewJSONDiffService()
+	tests := []struct {
+		desc                       string
+		base, ours, theirs, merged string
+	}{
+		{"追加与修改", `["a","b"]`, `["a","b","c"]`, `["A","b"]`, `["A","b","c"]`},
+		{"删除与修改", `["a","b","c"]`, `["a","B","c"]`, `["a","b"]`, `["a","B"]`},
+		{"两侧追加相同元素", `["a"]`, `["A","x"]`, `["a","x"]`, `["A","x"]`},
+		{"两侧追加不同元素", `["a"]`, `["a","x"]`, `["a","y"]`, `["a","x","y"]`},
+	}
+	for _, tt := range tests {
+		result, err := service.Merge3(tt.base, tt.ours, tt.theirs, ConflictFail)
+		if err != nil {
+			t.Errorf("%s: 合并时出错: %v", tt.desc, err)
+			continue
+		}
+		assertSameJSON(t, result.Merged, tt.merged)
+	}
+
+	// 一侧删除、另一侧修改同一元素时仍然是冲突
+	result, err := service.Merge3(`["a","b","c"]`, `["a","b"]`, `["a","b","C"]`, ConflictOurs)
+	if err != nil {
+		t.Fatalf("合并时出错: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "[2]" || !result.Conflicts[0].OursRemoved {
+		t.Errorf("预期[2]处删除与修改冲突，但实际为%v", result.Conflicts)
+	}
+	assertSameJSON(t, result.Merged, `["a","b"]`)
+}
+
+// TestMerge3_KeyedArrays 测试按标识字段对齐数组元素，一侧重新排序不会把修改合并到其他元素上
+func TestMerge3_KeyedArrays(t *testing.T) {
+	service := NewJSONDiffService(WithArrayKey("emps[*]", "id"))
+	base := `{"emps":[{"id":1,"n":"a"},{"id":2,"n":"b"}]}`
+	ours := `{"emps":[{"id":2,"n":"b"},{"id":1,"n":"a"}]}`
+	theirs := `{"emps":[{"id":1,"n":"A"},{"id":2,"n":"b"}]}`
+
+	for _, strategy := range []ConflictStrategy{ConflictFail, ConflictTheirs} {
+		result, err := service.Merge3(base, ours, theirs, strategy)
+		if err != nil {
+			t.Fatalf("合并时出错: %v", err)
+		}
+		if len(result.Conflicts) != 0 {
+			t.Errorf("预期没有冲突，但实际为%v", result.Conflicts)
+		}
+		assertSameJSON(t, result.Merged, `{"emps":[{"id":2,"n":"b"},{"id":1,"n":"A"}]}`)
+	}
+
+	// 只有上游重新排序时采用上游的顺序，两侧新增相同标识的元素合并为一个
+	result, err := service.Merge3(base,
+		`{"emps":[{"id":1,"n":"a"},{"id":2,"n":"B"},{"id":3,"n":"c","x":1}]}`,
+		`{"emps":[{"id":2,"n":"b"},{"id":1,"n":"a"},{"id":3,"n":"c","y":2}]}`, ConflictFail)
+	if err != nil {
+		t.Fatalf("合并时出错: %v", err)
+	}
+	assertSameJSON(t, result.Merged, `{"emps":[{"id":2,"n":"B"},{"id":1,"n":"a"},{"id":3,"n":"c","x":1,"y":2}]}`)
+
+	// 两侧按不同的顺序重新排列时整个数组是冲突
+	base = `{"emps":[{"id":1},{"id":2},{"id":3}]}`
+	result, err = service.Merge3(base, `{"emps":[{"id":2},{"id":1},{"id":3}]}`, `{"emps":[{"id":1},{"id":3},{"id":2}]}`, ConflictTheirs)
+	if err != nil {
+		t.Fatalf("合并时出错: %v", err)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Path != "emps" {
+		t.Errorf("预期emps整体冲突，但实际为%v", result.Conflicts)
+	}
+	assertSameJSON(t, result.Merged, `{"emps":[{"id":1},{"id":3},{"id":2}]}`)
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+)
+
+// pathValue 记录某个路径上的值
+type pathValue struct {
+	path  string
+	value interface{}
+}
+
+// isNonEmptyContainer 判断值是否为非空的对象或数组，只有这样的子树才参与跨位置的移动检测
+func isNonEmptyContainer(v interface{}) bool {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return len(t) > 0
+	case []interface{}:
+		return len(t) > 0
+	}
+	return false
+}
+
+// detectArrayMoves 在不属于公共子序列的元素中按结构哈希寻找相同的值，识别为数组内的移动并记录，
+// 返回两个数组中已被识别为移动的元素标记
+func (d *jsonDiffer) detectArrayMoves(path string, a1, a2 []interface{}, pairs [][2]int) ([]bool, []bool) {
+	common1, common2 := pairedMarks(len(a1), len(a2), pairs)
+	moves := equalPairs(a1, a2, common1, common2, d.elementEqual(path))
+	for _, m := range moves {
+		d.recordMoved(d.indexPath(path, m[0]), d.indexPath(path, m[1]), a1[m[0]], a2[m[1]])
+	}
+	return pairedMarks(len(a1), len(a2), moves)
+}
+
+// increasingPairs 对按第二个下标排列的配对，找出第一个下标构成的最长递增子序列，
+// 返回每个配对是否属于该子序列；不属于的配对即相对顺序发生了变化
+func increasingPairs(pairs [][2]int) []bool {
+	// tails[k] 为长度 k+1 的递增子序列末尾元素在 pairs 中的位置
+	var tails []int
+	prev := make([]int, len(pairs))
+	for p, pair := range pairs {
+		k := sort.Search(len(tails), func(k int) bool {
+			return pairs[tails[k]][0] >= pair[0]
+		})
+		if k > 0 {
+			prev[p] = tails[k-1]
+		} else {
+			prev[p] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, p)
+		} else {
+			tails[k] = p
+		}
+	}
+
+	inOrder := make([]bool, len(pairs))
+	if len(tails) > 0 {
+		for p := tails[len(tails)-1]; p >= 0; p = prev[p] {
+			inOrder[p] = true
+		}
+	}
+	return inOrder
+}
+
+// detectSubtreeMoves 在比较结束后，把被移除和被新增的相同子树配对为移动。
+// 子树可以移动到新增子树的内部，也可以来自被移除子树的内部；
+// 只有整棵被新增或被移除的子树参与配对时，才从新增和移除中删除对应路径
+func (d *jsonDiffer) detectSubtreeMoves() {
+	if len(d.addedTrees) == 0 || len(d.removedTrees) == 0 {
+		return
+	}
+
+	// 按结构哈希分组记录新增子树及其内部的所有非空对象和数组
+	var added []pathValue
+	for _, a := range d.addedTrees {
+		collectContainers(d.config.pathFormat, a.path, a.value, &added)
+	}
+	pending := make(map[uint64][]int)
+	for i, a := range added {
+		h := hashValue(a.value)
+		pending[h] = append(pending[h], i)
+	}
+
+	movedFrom := make(map[string]bool)
+	movedTo := make(map[string]bool)
+	var moves [][2]pathValue // 被配对的移除子树和新增子树
+	for _, r := range d.removedTrees {
+		var removed []pathValue
+		collectContainers(d.config.pathFormat, r.path, r.value, &removed)
+
+		matchedPath := ""
+		for _, candidate := range removed {
+			// 祖先已经作为整体移动时，其内部的值随之移动
+			if matchedPath != "" && d.config.pathFormat.isDescendant(candidate.path, matchedPath) {
+				continue
+			}
+			h := hashValue(candidate.value)
+			queue := pending[h]
+			for q, i := range queue {
+				if !reflect.DeepEqual(added[i].value, candidate.value) {
+					continue
+				}
+				pending[h] = append(queue[:q:q], queue[q+1:]...)
+				movedFrom[candidate.path] = true
+				movedTo[added[i].path] = true
+				matchedPath = candidate.path
+				moves = append(moves, [2]pathValue{candidate, added[i]})
+				break
+			}
+		}
+	}
+	if len(moves) == 0 {
+		return
+	}
+
+	// 先删除被配对的新增和移除，删除后腾出的数量可以用于记录移动
+	d.result.dropChanges(ChangeAdded, movedTo)
+	d.result.dropChanges(ChangeRemoved, movedFrom)
+	d.recorded = len(d.result.Changes)
+	for _, m := range moves {
+		d.recordMoved(m[0].path, m[1].path, m[0].value, m[1].value)
+	}
+}
+
+// collectContainers 按先序遍历收集值本身及其内部所有非空的对象和数组
+func collectContainers(f PathFormat, path string, v interface{}, out *[]pathValue) {
+	if !isNonEmptyContainer(v) {
+		return
+	}
+	*out = append(*out, pathValue{path: path, value: v})
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for _, k := range sortedKeys(t) {
+			collectContainers(f, f.key(path, k), t[k], out)
+		}
+	case []interface{}:
+		for i, e := range t {
+			collectContainers(f, f.index(path, i), e, out)
+		}
+	}
+}
+
+// filterPaths 返回不在 exclude 中的路径
+func filterPaths(paths []string, exclude map[string]bool) []string {
+	kept := paths[:0]
+	for _, p := range paths {
+		if !exclude[p] {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"testing"
+)
+
+// containsMove 检查移动列表中是否包含指定的移动
+func containsMove(moves []MovedPath, from, to string) bool {
+	for _, m := range moves {
+		if m.From == from && m.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TestCompareJSON_MoveInLCSArray 测试LCS模式下数组元素重新排序被识别为移动
+func TestCompareJSON_MoveInLCSArray(t *testing.T) {
+	service := NewJSONDiffService(WithArrayDiffMode(ArrayDiffLCS), WithMoveDetection())
+	json1 := `{"offices":[{"location":"New York"},{"location":"London"},{"location":"Berlin"}]}`
+	json2 := `{"offices":[{"location":"Berlin"},{"location":"New York"},{"location":"London"}]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("检测移动时出错: %v", err)
+		return
+	}
+
+	if len(diff.Moved) != 1 || !containsMove(diff.Moved, "offices[2]", "offices[0]") {
+		t.Errorf("预期只有'offices[2] -> offices[0]'的移动，但实际为: %v", diff.Moved)
+	}
+	if len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Changed) > 0 {
+		t.Errorf("预期没有其他差异，但实际新增: %v，移除: %v，变更: %v", diff.Added, diff.Removed, diff.Changed)
+	}
+}
+
+// TestCompareJSON_MoveWithGapChanges 测试移动检测不影响区间内剩余元素的比较
+func TestCompareJSON_MoveWithGapChanges(t *testing.T) {
+	service := NewJSONDiffService(WithArrayDiffMode(ArrayDiffLCS), WithMoveDetection())
+	json1 := `{"list":["a","b","c","d"]}`
+	json2 := `{"list":["d","a","x","c"]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("检测移动时出错: %v", err)
+		return
+	}
+
+	if !containsMove(diff.Moved, "list[3]", "list[0]") {
+		t.Errorf("预期识别'list[3] -> list[0]'的移动，但实际为: %v", diff.Moved)
+	}
+	if _, exists := diff.Changed["list[2]"]; !exists || len(diff.Changed) != 1 {
+		t.Errorf("预期只有'list[2]'发生变更，但实际为: %v", diff.Changed)
+	}
+}
+
+// TestCompareJSON_MoveInDefaultArrayMode 测试只启用移动检测时，默认策略的数组重新排序同样被识别为移动
+func TestCompareJSON_MoveInDefaultArrayMode(t *testing.T) {
+	service := NewJSONDiffService(WithMoveDetection())
+	json1 := `{"list":[1,2,3]}`
+	json2 := `{"list":[3,1,2]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("检测移动时出错: %v", err)
+		return
+	}
+
+	if len(diff.Moved) != 1 || !containsMove(diff.Moved, "list[2]", "list[0]") {
+		t.Errorf("预期只有'list[2] -> list[0]'的移动，但实际为: %v", diff.Moved)
+	}
+	if len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Changed) > 0 {
+		t.Errorf("预期没有其他差异，但实际新增: %v，移除: %v，变更: %v", diff.Added, diff.Removed, diff.Changed)
+	}
+}
+
+// TestCompareJSON_MoveInKeyedArray 测试按标识字段配对的数组中相对顺序变化的元素被识别为移动
+func TestCompareJSON_MoveInKeyedArray(t *testing.T) {
+	service := NewJSONDiffService(WithArrayKey("offices[*]", "location"), WithMoveDetection())
+	json1 := `{"offices":[{"location":"New York","size":1},{"location":"London","size":2}]}`
+	json2 := `{"offices":[{"location":"London","size":3},{"location":"New York","size":1}]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("检测移动时出错: %v", err)
+		return
+	}
+
+	if len(diff.Moved) != 1 || !(containsMove(diff.Moved, "offices[1]", "offices[0]") || containsMove(diff.Moved, "offices[0]", "offices[1]")) {
+		t.Errorf("预期有一个'offices'元素的移动，但实际为: %v", diff.Moved)
+	}
+	if _, exists := diff.Changed["offices[0].size"]; !exists {
+		t.Errorf("预期移动后的元素仍然报告'offices[0].size'变更，但实际为: %v", diff.Changed)
+	}
+}
+
+// TestCompareJSON_MoveSubtree 测试对象子树移动到其他键时被识别为移动
+func TestCompareJSON_MoveSubtree(t *testing.T) {
+	service := NewJSONDiffService(WithMoveDetection())
+	json1 := `{"config":{"db":{"host":"localhost","port":5432}},"count":1}`
+	json2 := `{"config":{"storage":{"primary":{"host":"localhost","port":5432}}},"total":1}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Errorf("检测移动时出错: %v", err)
+		return
+	}
+
+	if len(diff.Moved) != 1 || !containsMove(diff.Moved, "config.db", "config.storage.primary") {
+		t.Errorf("预期只有'config.db -> config.storage.primary'的移动，但实际为: %v", diff.Moved)
+	}
+	if containsPath(diff.Removed, "config.db") || !containsPath(diff.Removed, "count") {
+		t.Errorf("预期移除只包含'count'，但实际为: %v", diff.Removed)
+	}
+	if !containsPath(diff.Added, "config.storage") || !containsPath(diff.Added, "total") {
+		t.Errorf("预期新增包含'config.storage'和'total'，但实际为: %v", diff.Added)
+	}
+}
+
+// TestIncreasingPairs 测试最长递增子序列的标记
+func TestIncreasingPairs(t *testing.T) {
+	pairs := [][2]int{{2, 0}, {0, 1}, {1, 2}, {3, 3}}
+	expected := []bool{false, true, true, true}
+
+	inOrder := increasingPairs(pairs)
+	for i := range expected {
+		if inOrder[i] != expected[i] {
+			t.Errorf("预期标记为%v，但实际为%v", expected, inOrder)
+			break
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DetailLevel 表示差异结果的详细程度
+type DetailLevel int
+
+const (
+	// DetailFull 完整结果（默认）：Changes 中包含旧值和新值
+	DetailFull DetailLevel = iota
+	// DetailPaths 只保留路径和类型：Changes 中不包含旧值和新值，旧版 Changed 字段中的描述也只说明差异类型，
+	// 适合只关心哪些路径发生变化的大文档；这样的结果无法用 Invert 求逆
+	DetailPaths
+)
+
+// ErrLimitExceeded 表示输入超过了 WithMaxDocumentSize 或 WithMaxDepth 设置的限制
+var ErrLimitExceeded = errors.New("超过比较限制")
+
+// cancelCheckInterval 比较过程中每访问这么多个节点检查一次上下文是否已取消
+const cancelCheckInterval = 1024
+
+// WithIgnorePaths 添加忽略路径，语法与 CompareJSONWithIgnore 的忽略路径相同；
+// 创建服务时指定的忽略路径对每次比较都生效，与调用时指定的忽略路径合并使用
+func WithIgnorePaths(patterns ...string) Option {
+	return func(c *diffConfig) {
+		c.ignore = append(c.ignore, patterns...)
+	}
+}
+
+// WithMaxDocumentSize 限制每个JSON文本的字节数，超过时在解析之前返回 ErrLimitExceeded，0 表示不限制
+func WithMaxDocumentSize(bytes int) Option {
+	return func(c *diffConfig) {
+		c.maxBytes = bytes
+	}
+}
+
+// WithMaxDepth 限制对象和数组的嵌套层数，超过时返回 ErrLimitExceeded，0 表示不限制
+func WithMaxDepth(depth int) Option {
+	return func(c *diffConfig) {
+		c.maxDepth = depth
+	}
+}
+
+// WithMaxChanges 限制报告的差异数量，达到上限后停止比较并把结果的 Truncated 设为 true，0 表示不限制
+func WithMaxChanges(n int) Option {
+	return func(c *diffConfig) {
+		c.maxChanges = n
+	}
+}
+
+// WithDetail 设置差异结果的详细程度，默认为 DetailFull
+func WithDetail(level DetailLevel) Option {
+	return func(c *diffConfig) {
+		c.detail = level
+	}
+}
+
+// with 返回应用了 opts 并编译了规则的配置副本，不修改原配置
+func (c diffConfig) with(opts []Option) diffConfig {
+	if len(opts) == 0 {
+		c.rules = c.compileRules()
+		return c
+	}
+	// 限制切片的容量，使选项追加元素时复制底层数组而不是写入原配置共享的数组
+	c.arrayKeys = c.arrayKeys[:len(c.arrayKeys):len(c.arrayKeys)]
+	c.unordered = c.unordered[:len(c.unordered):len(c.unordered)]
+	c.tolerances = c.tolerances[:len(c.tolerances):len(c.tolerances)]
+	c.include = c.include[:len(c.include):len(c.include)]
+	c.ignore = c.ignore[:len(c.ignore):len(c.ignore)]
+	c.comparators = c.comparators[:len(c.comparators):len(c.comparators)]
+	if c.typeComparators != nil {
+		types := make(map[JSONType]Comparator, len(c.typeComparators))
+		for t, comparator := range c.typeComparators {
+			types[t] = comparator
+		}
+		c.typeComparators = types
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	c.rules = c.compileRules()
+	return c
+}
+
+// checkSize 检查两个JSON文本的字节数是否超过限制
+func (c *diffConfig) checkSize(size1, size2 int) error {
+	if c.maxBytes <= 0 {
+		return nil
+	}
+	for i, size := range []int{size1, size2} {
+		if size > c.maxBytes {
+			return fmt.Errorf("%w: 第%s个JSON大小为 %d 字节，上限为 %d 字节", ErrLimitExceeded, ordinals[i], size, c.maxBytes)
+		}
+	}
+	return nil
+}
+
+// checkDepth 检查两个文档的嵌套层数是否超过限制
+func (c *diffConfig) checkDepth(v1, v2 interface{}) error {
+	if c.maxDepth <= 0 {
+		return nil
+	}
+	for i, v := range []interface{}{v1, v2} {
+		if exceedsDepth(v, c.maxDepth) {
+			return fmt.Errorf("%w: 第%s个JSON的嵌套层数超过 %d", ErrLimitExceeded, ordinals[i], c.maxDepth)
+		}
+	}
+	return nil
+}
+
+// ordinals 错误信息中使用的序数
+var ordinals = []string{"一", "二"}
+
+// exceedsDepth 判断值中对象和数组的嵌套层数是否超过 depth
+func exceedsDepth(v interface{}, depth int) bool {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if depth == 0 {
+			return true
+		}
+		for _, child := range t {
+			if exceedsDepth(child, depth-1) {
+				return true
+			}
+		}
+	case []interface{}:
+		if depth == 0 {
+			return true
+		}
+		for _, child := range t {
+			if exceedsDepth(child, depth-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stopped 检查是否应该停止比较：差异数量已达到上限、sink 要求停止，或者上下文已取消；
+// 上下文在访问第一个节点时检查，之后每隔 cancelCheckInterval 个节点检查一次
+func (d *jsonDiffer) stopped() bool {
+	if d.err != nil || d.truncated {
+		return true
+	}
+	d.visited++
+	if d.visited%cancelCheckInterval == 1 {
+		if err := d.ctx.Err(); err != nil {
+			d.err = err
+			return true
+		}
+	}
+	return false
+}
+
+// full 检查差异数量是否已达到上限，达到时标记结果被截断
+func (d *jsonDiffer) full() bool {
+	if d.config.maxChanges > 0 && d.recorded >= d.config.maxChanges {
+		d.truncated = true
+	}
+	return d.truncated
+}
+
+// stripValues 按 DetailPaths 去掉差异记录中的旧值和新值，并重新生成旧版 Changed 字段中的描述
+func (r *JSONDiffResult) stripValues() {
+	for i := range r.Changes {
+		c := &r.Changes[i]
+		c.OldValue, c.NewValue = nil, nil
+		switch c.Kind {
+		case ChangeValueChanged, ChangeTypeChanged, ChangeLengthChanged:
+			r.Changed[c.Path] = c.describe()
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCompare_PerCallOptions 测试调用时的选项只对本次比较生效，并与创建服务时的配置合并
+func TestCompare_PerCallOptions(t *testing.T) {
+	service := NewJSONDiffService(WithIgnorePaths("ts"), WithArrayKey("items", "id"))
+	json1 := `{"ts":1,"a/b":1,"items":[{"id":1,"v":1},{"id":2,"v":1}],"n":1}`
+	json2 := `{"ts":2,"a/b":2,"items":[{"id":2,"v":1},{"id":1,"v":2}],"n":1.0000001}`
+
+	diff, err := service.Compare(context.Background(), json1, json2,
+		WithIgnorePaths("a/b"),
+		WithTolerance("n", Tolerance{Absolute: 1e-3}),
+		WithResultOrder(OrderPath),
+	)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, []string{"items[1].v"}) {
+		t.Errorf("变更路径为%v，预期[items[1].v]", paths)
+	}
+
+	// 调用时的选项不影响服务的配置
+	diff, err = service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, []string{"a/b", "items[1].v", "n"}) {
+		t.Errorf("变更路径为%v，预期[a/b items[1].v n]", paths)
+	}
+}
+
+// TestCompare_OptionsDoNotShareSlices 测试调用时追加的规则不会写入服务配置共享的底层数组
+func TestCompare_OptionsDoNotShareSlices(t *testing.T) {
+	ignore := make([]string, 1, 4)
+	ignore[0] = "a"
+	s := &jsonDiffServiceImpl{}
+	WithIgnorePaths(ignore...)(&s.config)
+
+	first := s.config.with([]Option{WithIgnorePaths("b")})
+	second := s.config.with([]Option{WithIgnorePaths("c")})
+	if !reflect.DeepEqual(first.ignore, []string{"a", "b"}) || !reflect.DeepEqual(second.ignore, []string{"a", "c"}) {
+		t.Errorf("两次比较的忽略路径互相影响: %v, %v", first.ignore, second.ignore)
+	}
+	if len(s.config.ignore) != 1 {
+		t.Errorf("服务配置被修改: %v", s.config.ignore)
+	}
+}
+
+// TestCompare_Limits 测试文档大小、嵌套层数和差异数量的限制
+func TestCompare_Limits(t *testing.T) {
+	service := NewJSONDiffService(WithMaxDocumentSize(20))
+	if _, err := service.CompareJSON(`{"a":1}`, `{"a":"a long string value"}`); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("预期返回ErrLimitExceeded，实际为%v", err)
+	}
+
+	service = NewJSONDiffService(WithMaxDepth(2))
+	if _, err := service.CompareJSON(`{"a":[1]}`, `{"a":[2]}`); err != nil {
+		t.Errorf("嵌套两层不应超过限制: %v", err)
+	}
+	if _, err := service.CompareJSON(`{"a":[1]}`, `{"a":[{"b":1}]}`); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("预期返回ErrLimitExceeded，实际为%v", err)
+	}
+
+	service = NewJSONDiffService(WithMaxChanges(2))
+	diff, err := service.CompareJSON(`{"a":1,"b":1,"c":1}`, `{"a":2,"b":2,"c":2}`)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if !diff.Truncated || len(diff.Changes) != 2 || len(diff.Changed) != 2 {
+		t.Errorf("预期报告2处差异并标记截断，实际为%+v", diff)
+	}
+	diff, err = service.CompareJSON(`{"a":1,"b":1}`, `{"a":2,"b":2}`)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if diff.Truncated {
+		t.Errorf("差异数量恰好等于上限时不应标记截断")
+	}
+}
+
+// TestCompare_DetailPaths 测试只保留路径的结果
+func TestCompare_DetailPaths(t *testing.T) {
+	service := NewJSONDiffService()
+	diff, err := service.Compare(context.Background(), `{"a":1,"b":{"c":1}}`, `{"a":"x"}`, WithDetail(DetailPaths))
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if len(diff.Changes) != 2 {
+		t.Fatalf("预期2处差异，实际为%+v", diff.Changes)
+	}
+	for _, c := range diff.Changes {
+		if c.OldValue != nil || c.NewValue != nil {
+			t.Errorf("%s的差异记录不应包含值: %+v", c.Path, c)
+		}
+	}
+	if diff.Changes[0].OldType != JSONNumber || diff.Changes[0].NewType != JSONString {
+		t.Errorf("差异记录应保留类型: %+v", diff.Changes[0])
+	}
+
+	diff, err = service.Compare(context.Background(), `{"s":"secret","t":1,"n":null,"l":[1]}`, `{"s":"other","t":"x","n":2,"l":[1,2]}`, WithDetail(DetailPaths))
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	expected := map[string]string{"s": "值变更", "t": "类型变更: number -> string", "n": "类型变更: null -> number", "l": "数组长度变更"}
+	if !reflect.DeepEqual(diff.Changed, expected) {
+		t.Errorf("Changed中的描述不应包含值，实际为%v，预期%v", diff.Changed, expected)
+	}
+}
+
+// TestCompare_Canceled 测试上下文取消时停止比较
+func TestCompare_Canceled(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("[")
+	for i := 0; i < 5000; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"v":%d}`, i)
+	}
+	b.WriteString("]")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewJSONDiffService().Compare(ctx, b.String(), b.String())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("预期返回context.Canceled，实际为%v", err)
+	}
+}
+
+// TestCompare_CanceledBeforeStart 测试上下文在比较开始前已取消时，小文档同样返回上下文的错误
+func TestCompare_CanceledBeforeStart(t *testing.T) {
+	service := NewJSONDiffService()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	json1, json2 := `{"a":1}`, `{"a":1}`
+
+	if _, err := service.Compare(ctx, json1, json2); !errors.Is(err, context.Canceled) {
+		t.Errorf("Compare应该返回context.Canceled，实际为%v", err)
+	}
+	if err := service.CompareTo(ctx, json1, json2, &JSONDiffResult{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("CompareTo应该返回context.Canceled，实际为%v", err)
+	}
+	if equal, err := service.Equal(ctx, json1, json2); equal || !errors.Is(err, context.Canceled) {
+		t.Errorf("Equal应该返回false和context.Canceled，实际为%v、%v", equal, err)
+	}
+	_, err := service.CompareStream(ctx, strings.NewReader(json1), strings.NewReader(json2), func(Change) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("CompareStream应该返回context.Canceled，实际为%v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"reflect"
+	"sort"
+)
+
+// ResultOrder 表示差异结果的排列顺序
+type ResultOrder int
+
+const (
+	// OrderDocument 文档顺序（默认）：对象成员按在JSON文本中出现的顺序比较和报告，
+	// 只在第二个JSON中出现的成员排在同一对象的其他成员之后，移动检测识别出的子树移动排在最后
+	OrderDocument ResultOrder = iota
+	// OrderPath 路径顺序：所有差异按路径排序，键按字典序比较，数组下标按数值比较
+	OrderPath
+)
+
+// WithResultOrder 设置差异结果的排列顺序，两种顺序都是确定的，
+// 同样影响生成补丁、合并补丁和三方合并时对象成员的处理顺序
+func WithResultOrder(order ResultOrder) Option {
+	return func(c *diffConfig) {
+		c.order = order
+	}
+}
+
+// keyOrder 记录解析JSON时对象成员在文本中出现的顺序，以对象的地址为键
+type keyOrder map[uintptr][]string
+
+// newKeyOrder 按配置返回用于记录成员顺序的表，按路径排序时不需要记录，返回 nil
+func (c *diffConfig) newKeyOrder() keyOrder {
+	if c.order == OrderDocument {
+		return keyOrder{}
+	}
+	return nil
+}
+
+// keys 返回对象成员的顺序，没有记录时按字典序排列
+func (o keyOrder) keys(m map[string]interface{}) []string {
+	if keys, ok := o[reflect.ValueOf(m).Pointer()]; ok && len(keys) == len(m) {
+		return keys
+	}
+	return sortedKeys(m)
+}
+
+// sortByPath 按路径顺序重新排列差异记录，并按新的顺序重建旧版字段
+func (r *JSONDiffResult) sortByPath(f PathFormat) {
+	keys := make(map[string][]pathSegment, len(r.Changes))
+	for _, c := range r.Changes {
+		if _, exists := keys[c.Path]; !exists {
+			keys[c.Path], _ = f.segments(c.Path)
+		}
+	}
+	changes := r.Changes
+	sort.SliceStable(changes, func(i, j int) bool {
+		return compareSegments(keys[changes[i].Path], keys[changes[j].Path]) < 0
+	})
+
+	*r = JSONDiffResult{
+		Added:   []string{},
+		Removed: []string{},
+		Changed: make(map[string]string),
+		Moved:   []MovedPath{},
+	}
+	for _, c := range changes {
+		r.appendChange(c)
+	}
+}
+
+// compareSegments 比较两个路径的先后：逐段比较，数组下标按数值排在键之前，较短的前缀排在前面
+func compareSegments(a, b []pathSegment) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, y := a[i], b[i]
+		switch {
+		case x.isIndex && !y.isIndex:
+			return -1
+		case !x.isIndex && y.isIndex:
+			return 1
+		case x.isIndex && len(x.key) != len(y.key):
+			// 下标没有前导零，位数少的数值更小
+			if len(x.key) < len(y.key) {
+				return -1
+			}
+			return 1
+		case x.key != y.key:
+			if x.key < y.key {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// ChangedPaths 按差异记录的顺序返回 Changed 中的路径，用于按确定的顺序输出 Changed
+func (r JSONDiffResult) ChangedPaths() []string {
+	paths := make([]string, 0, len(r.Changed))
+	for _, c := range r.Changes {
+		if _, exists := r.Changed[c.Path]; exists && c.Kind != ChangeAdded && c.Kind != ChangeRemoved && c.Kind != ChangeMoved {
+			paths = append(paths, c.Path)
+		}
+	}
+	return paths
+}
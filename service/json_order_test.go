@@ -0,0 +1,100 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCompareJSON_DocumentOrder 测试默认按文档顺序报告差异，多次比较的结果相同
+func TestCompareJSON_DocumentOrder(t *testing.T) {
+	service := NewJSONDiffService()
+	json1 := `{"z":1,"a":1,"m":{"y":1,"b":1},"k":1,"q":1,"c":1}`
+	json2 := `{"m":{"b":2,"y":2},"c":2,"k":2,"x":1,"d":1}`
+
+	for i := 0; i < 20; i++ {
+		diff, err := service.CompareJSON(json1, json2)
+		if err != nil {
+			t.Fatalf("比较JSON时出错: %v", err)
+		}
+		if !reflect.DeepEqual(diff.Removed, []string{"z", "a", "q"}) {
+			t.Fatalf("移除路径为%v，预期按第一个JSON的顺序排列", diff.Removed)
+		}
+		if !reflect.DeepEqual(diff.Added, []string{"x", "d"}) {
+			t.Fatalf("新增路径为%v，预期按第二个JSON的顺序排列", diff.Added)
+		}
+		if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, []string{"m.y", "m.b", "k", "c"}) {
+			t.Fatalf("变更路径为%v，预期按第一个JSON的顺序排列", paths)
+		}
+	}
+}
+
+// TestCompareJSON_PathOrder 测试按路径顺序报告差异，数组下标按数值排序
+func TestCompareJSON_PathOrder(t *testing.T) {
+	service := NewJSONDiffService(WithResultOrder(OrderPath))
+	json1 := `{"z":1,"b":{"y":1},"list":[0,0,0,0,0,0,0,0,0,0,0,0]}`
+	json2 := `{"b":{"y":2,"a":1},"a":1,"list":[0,0,1,0,0,0,0,0,0,0,1]}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if !reflect.DeepEqual(diff.Added, []string{"a", "b.a"}) {
+		t.Errorf("新增路径为%v，预期[a b.a]", diff.Added)
+	}
+	expected := []string{"b.y", "list", "list[2]", "list[10]"}
+	if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("变更路径为%v，预期%v", paths, expected)
+	}
+	for i := 1; i < len(diff.Changes); i++ {
+		prev, _ := PathDotted.segments(diff.Changes[i-1].Path)
+		cur, _ := PathDotted.segments(diff.Changes[i].Path)
+		if compareSegments(prev, cur) > 0 {
+			t.Errorf("差异记录%s排在%s之前", diff.Changes[i-1].Path, diff.Changes[i].Path)
+		}
+	}
+}
+
+// TestCreatePatch_DocumentOrder 测试生成补丁时按文档顺序处理对象成员
+func TestCreatePatch_DocumentOrder(t *testing.T) {
+	json1, json2 := `{"z":1,"a":1,"m":1}`, `{"m":2,"y":1,"b":1}`
+	testCases := []struct {
+		order    ResultOrder
+		expected string
+	}{
+		{OrderDocument, `[{"op":"remove","path":"/z"},{"op":"remove","path":"/a"},{"op":"replace","path":"/m","value":2},{"op":"add","path":"/y","value":1},{"op":"add","path":"/b","value":1}]`},
+		{OrderPath, `[{"op":"remove","path":"/a"},{"op":"replace","path":"/m","value":2},{"op":"remove","path":"/z"},{"op":"add","path":"/b","value":1},{"op":"add","path":"/y","value":1}]`},
+	}
+
+	for _, tc := range testCases {
+		patch, err := NewJSONDiffService(WithResultOrder(tc.order)).CreatePatch(json1, json2)
+		if err != nil {
+			t.Fatalf("生成补丁时出错: %v", err)
+		}
+		if actual := patchString(t, patch); actual != tc.expected {
+			t.Errorf("补丁为%s，预期%s", actual, tc.expected)
+		}
+	}
+}
+
+// TestParseDocument_KeyOrder 测试解析时记录成员顺序，无效的JSON返回错误
+func TestParseDocument_KeyOrder(t *testing.T) {
+	order := keyOrder{}
+	v, err := (&diffConfig{}).parseDocument(`{"b":1,"a":{"d":[{"y":1,"x":2}],"c":null},"b":2}`, order)
+	if err != nil {
+		t.Fatalf("解析JSON时出错: %v", err)
+	}
+	m := v.(map[string]interface{})
+	if keys := order.keys(m); !reflect.DeepEqual(keys, []string{"b", "a"}) || m["b"] != 2.0 {
+		t.Errorf("成员顺序为%v，b的值为%v", keys, m["b"])
+	}
+	inner := m["a"].(map[string]interface{})["d"].([]interface{})[0].(map[string]interface{})
+	if keys := order.keys(inner); !reflect.DeepEqual(keys, []string{"y", "x"}) {
+		t.Errorf("嵌套对象的成员顺序为%v", keys)
+	}
+
+	for _, invalid := range []string{``, `{"a":1`, `{"a":1}}`, `[1,]`, `{"a" 1}`} {
+		if _, err := (&diffConfig{}).parseDocument(invalid, keyOrder{}); err == nil {
+			t.Errorf("预期解析%q时出错，但实际没有", invalid)
+		}
+	}
+}
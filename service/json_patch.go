@@ -0,0 +1,251 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// JSON Patch 操作类型（RFC 6902）
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// PatchOperation 表示 RFC 6902 JSON Patch 中的一个操作，路径使用 JSON Pointer（RFC 6901）
+type PatchOperation struct {
+	Op    string      `json:"op"`              // 操作类型
+	From  string      `json:"from,omitempty"`  // move 和 copy 操作的来源路径
+	Path  string      `json:"path"`            // 操作的目标路径
+	Value interface{} `json:"value,omitempty"` // add、replace 和 test 操作使用的值
+}
+
+// JSONPatch 表示一个完整的 JSON Patch 文档，操作按顺序执行
+type JSONPatch []PatchOperation
+
+// MarshalJSON 序列化操作，add、replace 和 test 操作即使值为 null 也保留 value 成员
+func (op PatchOperation) MarshalJSON() ([]byte, error) {
+	type plain PatchOperation
+	if op.Op != PatchAdd && op.Op != PatchReplace && op.Op != PatchTest {
+		return json.Marshal(plain(op))
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{op.Op, op.Path, op.Value})
+}
+
+// CreatePatch 计算把第一个JSON转换为第二个JSON的 JSON Patch，
+// 按顺序把补丁应用到第一个JSON上得到的结果与第二个JSON相同。
+// 数组元素按服务配置的数组策略配对，值相同但位置变化的元素生成 move 操作，
+// 新增的值与第一个JSON中未被修改的对象成员相同时生成 copy 操作
+func (s *jsonDiffServiceImpl) CreatePatch(json1, json2 string) (JSONPatch, error) {
+	config := s.config.with(nil)
+	order := config.newKeyOrder()
+	obj1, obj2, err := config.parseJSONPair(json1, json2, order)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &patchBuilder{config: &config, order: order, ops: JSONPatch{}}
+	b.diff("", "", obj1, obj2)
+	b.detectCopies(obj1)
+	return b.ops, nil
+}
+
+// patchBuilder 在遍历两个值的过程中生成补丁操作
+type patchBuilder struct {
+	config *diffConfig
+	order  keyOrder
+	ops    JSONPatch
+}
+
+// diff 生成把 v1 转换为 v2 的操作，path 为用于匹配数组规则的路径，ptr 为当前值的 JSON Pointer
+func (b *patchBuilder) diff(path, ptr string, v1, v2 interface{}) {
+	if reflect.DeepEqual(v1, v2) {
+		return
+	}
+
+	switch t := v1.(type) {
+	case map[string]interface{}:
+		if m2, ok := v2.(map[string]interface{}); ok {
+			b.diffObjects(path, ptr, t, m2)
+			return
+		}
+	case []interface{}:
+		if a2, ok := v2.([]interface{}); ok {
+			b.diffArrays(path, ptr, t, a2)
+			return
+		}
+	}
+	b.replace(ptr, v1, v2)
+}
+
+// diffObjects 生成对象成员的移除、修改和新增操作
+func (b *patchBuilder) diffObjects(path, ptr string, m1, m2 map[string]interface{}) {
+	for _, k := range b.order.keys(m1) {
+		if v2, exists := m2[k]; exists {
+			b.diff(b.config.pathFormat.key(path, k), pointerKey(ptr, k), m1[k], v2)
+		} else {
+			b.remove(pointerKey(ptr, k), m1[k])
+		}
+	}
+	for _, k := range b.order.keys(m2) {
+		if _, exists := m1[k]; !exists {
+			b.add(pointerKey(ptr, k), m2[k])
+		}
+	}
+}
+
+// diffArrays 生成数组的补丁操作，分三步进行：
+// 先在原下标上修改配对的元素，再从后向前移除未配对的元素，
+// 最后按目标顺序逐个放置元素，位置不对的元素用 move 调整，缺少的元素用 add 插入
+func (b *patchBuilder) diffArrays(path, ptr string, a1, a2 []interface{}) {
+	pairs := b.config.arrayPairs(path, a1, a2, nil)
+	paired1, paired2 := pairedMarks(len(a1), len(a2), pairs)
+	// 未配对但值相同的元素通过移动复用
+	pairs = append(pairs, equalPairs(a1, a2, paired1, paired2, nil)...)
+
+	source := make([]int, len(a2))
+	for j := range source {
+		source[j] = -1
+	}
+	kept := make([]bool, len(a1))
+	for _, p := range pairs {
+		source[p[1]] = p[0]
+		kept[p[0]] = true
+		b.diff(b.config.pathFormat.index(path, p[1]), pointerIndex(ptr, p[0]), a1[p[0]], a2[p[1]])
+	}
+
+	for i := len(a1) - 1; i >= 0; i-- {
+		if !kept[i] {
+			b.remove(pointerIndex(ptr, i), a1[i])
+		}
+	}
+
+	// current 记录当前数组中每个位置上元素在第一个数组中的下标，新插入的元素记为 -1
+	var current []int
+	for i := range a1 {
+		if kept[i] {
+			current = append(current, i)
+		}
+	}
+	for j, i := range source {
+		if i < 0 {
+			b.add(pointerIndex(ptr, j), a2[j])
+			current = insertAt(current, j, -1)
+			continue
+		}
+		// 目标位置之前的元素都已就位，因此当前位置 k 不会小于 j
+		k := j
+		for current[k] != i {
+			k++
+		}
+		if k != j {
+			b.move(pointerIndex(ptr, k), pointerIndex(ptr, j))
+			current = insertAt(append(current[:k], current[k+1:]...), j, i)
+		}
+	}
+}
+
+// insertAt 在切片的指定位置插入一个元素
+func insertAt(s []int, index, v int) []int {
+	s = append(s, 0)
+	copy(s[index+1:], s[index:])
+	s[index] = v
+	return s
+}
+
+// detectCopies 把新增值与第一个JSON中某个对象成员相同的 add 操作改写为 copy 操作。
+// 来源只考虑从根开始全部由对象成员构成的路径，并且补丁中没有任何操作触及该路径及其祖先或后代，
+// 这样来源在整个补丁执行期间都保持第一个JSON中的值
+func (b *patchBuilder) detectCopies(doc interface{}) {
+	sources := make(map[uint64][]pathValue)
+	collectCopySources("", doc, sources)
+	if len(sources) == 0 {
+		return
+	}
+
+	for n, op := range b.ops {
+		if op.Op != PatchAdd || !isNonEmptyContainer(op.Value) {
+			continue
+		}
+		for _, src := range sources[hashValue(op.Value)] {
+			if reflect.DeepEqual(src.value, op.Value) && !b.touches(src.path) {
+				b.ops[n] = PatchOperation{Op: PatchCopy, From: src.path, Path: op.Path}
+				break
+			}
+		}
+	}
+}
+
+// collectCopySources 按结构哈希收集只经过对象成员即可到达的非空对象和数组
+func collectCopySources(ptr string, v interface{}, sources map[uint64][]pathValue) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, k := range sortedKeys(m) {
+		child := pointerKey(ptr, k)
+		if isNonEmptyContainer(m[k]) {
+			h := hashValue(m[k])
+			sources[h] = append(sources[h], pathValue{path: child, value: m[k]})
+		}
+		collectCopySources(child, m[k], sources)
+	}
+}
+
+// touches 判断补丁中是否有操作作用于指定路径、其祖先或其后代
+func (b *patchBuilder) touches(ptr string) bool {
+	for _, op := range b.ops {
+		if op.Op == PatchTest {
+			continue
+		}
+		if pointerRelated(op.Path, ptr) || (op.Op == PatchMove && pointerRelated(op.From, ptr)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pointerRelated 判断两个 JSON Pointer 是否相同或存在祖先与后代关系
+func pointerRelated(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a == b || strings.HasPrefix(b, a+"/")
+}
+
+// add 生成 add 操作
+func (b *patchBuilder) add(ptr string, v interface{}) {
+	b.ops = append(b.ops, PatchOperation{Op: PatchAdd, Path: ptr, Value: v})
+}
+
+// remove 生成 remove 操作，需要时先校验旧值
+func (b *patchBuilder) remove(ptr string, old interface{}) {
+	b.test(ptr, old)
+	b.ops = append(b.ops, PatchOperation{Op: PatchRemove, Path: ptr})
+}
+
+// replace 生成 replace 操作，需要时先校验旧值
+func (b *patchBuilder) replace(ptr string, old, v interface{}) {
+	b.test(ptr, old)
+	b.ops = append(b.ops, PatchOperation{Op: PatchReplace, Path: ptr, Value: v})
+}
+
+// move 生成 move 操作
+func (b *patchBuilder) move(from, ptr string) {
+	b.ops = append(b.ops, PatchOperation{Op: PatchMove, From: from, Path: ptr})
+}
+
+// test 在启用校验时生成 test 操作
+func (b *patchBuilder) test(ptr string, v interface{}) {
+	if b.config.tests {
+		b.ops = append(b.ops, PatchOperation{Op: PatchTest, Path: ptr, Value: v})
+	}
+}
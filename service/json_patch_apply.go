@@ -0,0 +1,341 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PatchError 表示应用 JSON Patch 时某个操作失败
+type PatchError struct {
+	Index int    // 失败操作在补丁中的下标
+	Op    string // 失败操作的类型
+	Path  string // 失败操作的目标路径
+	Err   error  // 失败原因
+}
+
+// Error 返回错误描述
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("补丁操作 %d（%s %q）失败: %v", e.Index, e.Op, e.Path, e.Err)
+}
+
+// Unwrap 返回失败原因
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+// ApplyPatch 把 RFC 6902 JSON Patch 应用到JSON文档上并返回结果。
+// 操作按顺序执行，任一操作失败（包括 test 操作校验不通过）时整个补丁都不生效，
+// 返回原文档和标明失败操作下标及路径的 *PatchError
+func (s *jsonDiffServiceImpl) ApplyPatch(doc, patch string) (string, error) {
+	obj, err := s.config.parseDocument(doc, nil)
+	if err != nil {
+		return doc, fmt.Errorf("解析JSON文档失败: %v", err)
+	}
+	ops, err := s.config.parseJSONPatch(patch)
+	if err != nil {
+		return doc, err
+	}
+
+	result, err := applyPatch(obj, ops)
+	if err != nil {
+		return doc, err
+	}
+	return marshalDocument(result)
+}
+
+// ApplyMergePatch 把 RFC 7386 JSON Merge Patch 应用到JSON文档上并返回结果
+func (s *jsonDiffServiceImpl) ApplyMergePatch(doc, patch string) (string, error) {
+	obj, err := s.config.parseDocument(doc, nil)
+	if err != nil {
+		return doc, fmt.Errorf("解析JSON文档失败: %v", err)
+	}
+	mp, err := s.config.parseDocument(patch, nil)
+	if err != nil {
+		return doc, fmt.Errorf("解析合并补丁失败: %v", err)
+	}
+	return marshalDocument(applyMergePatch(obj, mp))
+}
+
+// marshalDocument 把解析后的值编码为JSON字符串
+func marshalDocument(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("序列化JSON文档失败: %v", err)
+	}
+	return string(data), nil
+}
+
+// parseJSONPatch 按配置的数字方式解析 JSON Patch 文档并校验每个操作的成员
+func (c *diffConfig) parseJSONPatch(patch string) (JSONPatch, error) {
+	v, err := c.parseDocument(patch, nil)
+	if err != nil {
+		return nil, fmt.Errorf("解析补丁失败: %v", err)
+	}
+	raw, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("解析补丁失败: 补丁必须是数组")
+	}
+
+	ops := make(JSONPatch, len(raw))
+	for i, e := range raw {
+		m, ok := e.(map[string]interface{})
+		if !ok {
+			return nil, &PatchError{Index: i, Err: fmt.Errorf("操作必须是对象")}
+		}
+		op, _ := m["op"].(string)
+		path, ok := m["path"].(string)
+		if !ok {
+			return nil, &PatchError{Index: i, Op: op, Err: fmt.Errorf("缺少 path 成员")}
+		}
+		ops[i] = PatchOperation{Op: op, Path: path}
+
+		switch op {
+		case PatchAdd, PatchReplace, PatchTest:
+			value, exists := m["value"]
+			if !exists {
+				return nil, &PatchError{Index: i, Op: op, Path: path, Err: fmt.Errorf("缺少 value 成员")}
+			}
+			ops[i].Value = value
+		case PatchMove, PatchCopy:
+			from, ok := m["from"].(string)
+			if !ok {
+				return nil, &PatchError{Index: i, Op: op, Path: path, Err: fmt.Errorf("缺少 from 成员")}
+			}
+			ops[i].From = from
+		case PatchRemove:
+		default:
+			return nil, &PatchError{Index: i, Op: op, Path: path, Err: fmt.Errorf("未知的操作类型")}
+		}
+	}
+	return ops, nil
+}
+
+// applyPatch 把补丁应用到文档的副本上，任一操作失败时返回错误且不修改原文档
+func applyPatch(doc interface{}, patch JSONPatch) (interface{}, error) {
+	result := deepCopy(doc)
+	for i, op := range patch {
+		var err error
+		if result, err = applyOperation(result, op); err != nil {
+			return doc, &PatchError{Index: i, Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+	return result, nil
+}
+
+// applyOperation 执行单个补丁操作并返回新的文档根
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	tokens, err := parsePointer(op.Path)
+	if err != nil {
+		return doc, err
+	}
+
+	switch op.Op {
+	case PatchAdd:
+		return addValue(doc, tokens, deepCopy(op.Value))
+	case PatchRemove:
+		if len(tokens) == 0 {
+			return doc, fmt.Errorf("不能移除整个文档")
+		}
+		doc, _, err = removeValue(doc, tokens)
+		return doc, err
+	case PatchReplace:
+		if _, err := getValue(doc, tokens); err != nil {
+			return doc, err
+		}
+		return setValue(doc, tokens, deepCopy(op.Value))
+	case PatchMove, PatchCopy:
+		fromTokens, err := parsePointer(op.From)
+		if err != nil {
+			return doc, err
+		}
+		if op.Op == PatchMove {
+			if op.From == op.Path {
+				return doc, nil
+			}
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return doc, fmt.Errorf("不能把值移动到自身内部: %q", op.From)
+			}
+			if len(fromTokens) == 0 {
+				return doc, fmt.Errorf("不能移动整个文档")
+			}
+			var value interface{}
+			if doc, value, err = removeValue(doc, fromTokens); err != nil {
+				return doc, err
+			}
+			return addValue(doc, tokens, value)
+		}
+		value, err := getValue(doc, fromTokens)
+		if err != nil {
+			return doc, err
+		}
+		return addValue(doc, tokens, deepCopy(value))
+	case PatchTest:
+		value, err := getValue(doc, tokens)
+		if err != nil {
+			return doc, err
+		}
+		if !reflect.DeepEqual(value, op.Value) {
+			return doc, fmt.Errorf("校验失败: 实际值与预期值不同")
+		}
+		return doc, nil
+	}
+	return doc, fmt.Errorf("未知的操作类型")
+}
+
+// getValue 返回引用标记指向的值
+func getValue(doc interface{}, tokens []string) (interface{}, error) {
+	node := doc
+	for _, tok := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, exists := n[tok]
+			if !exists {
+				return nil, fmt.Errorf("成员不存在: %q", tok)
+			}
+			node = child
+		case []interface{}:
+			index, err := parseArrayIndex(tok, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("无法在非容器值中引用 %q", tok)
+		}
+	}
+	return node, nil
+}
+
+// updateParent 找到最后一个引用标记的父容器，用 fn 计算新的父容器并写回，返回新的文档根
+func updateParent(doc interface{}, tokens []string, fn func(parent interface{}, tok string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	tok := tokens[0]
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		child, exists := n[tok]
+		if !exists {
+			return doc, fmt.Errorf("成员不存在: %q", tok)
+		}
+		updated, err := updateParent(child, tokens[1:], fn)
+		if err != nil {
+			return doc, err
+		}
+		n[tok] = updated
+		return n, nil
+	case []interface{}:
+		index, err := parseArrayIndex(tok, len(n), false)
+		if err != nil {
+			return doc, err
+		}
+		updated, err := updateParent(n[index], tokens[1:], fn)
+		if err != nil {
+			return doc, err
+		}
+		n[index] = updated
+		return n, nil
+	}
+	return doc, fmt.Errorf("无法在非容器值中引用 %q", tok)
+}
+
+// addValue 按 add 操作的语义写入值：对象成员存在时替换，数组在指定下标处插入
+func addValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(parent interface{}, tok string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[tok] = value
+			return p, nil
+		case []interface{}:
+			index, err := parseArrayIndex(tok, len(p), true)
+			if err != nil {
+				return parent, err
+			}
+			p = append(p, nil)
+			copy(p[index+1:], p[index:])
+			p[index] = value
+			return p, nil
+		}
+		return parent, fmt.Errorf("无法在非容器值中添加 %q", tok)
+	})
+}
+
+// setValue 替换已经存在的值
+func setValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, tokens, func(parent interface{}, tok string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[tok] = value
+			return p, nil
+		case []interface{}:
+			index, err := parseArrayIndex(tok, len(p), false)
+			if err != nil {
+				return parent, err
+			}
+			p[index] = value
+			return p, nil
+		}
+		return parent, fmt.Errorf("无法在非容器值中替换 %q", tok)
+	})
+}
+
+// removeValue 移除引用标记指向的值，返回新的文档根和被移除的值
+func removeValue(doc interface{}, tokens []string) (interface{}, interface{}, error) {
+	var removed interface{}
+	doc, err := updateParent(doc, tokens, func(parent interface{}, tok string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			value, exists := p[tok]
+			if !exists {
+				return parent, fmt.Errorf("成员不存在: %q", tok)
+			}
+			removed = value
+			delete(p, tok)
+			return p, nil
+		case []interface{}:
+			index, err := parseArrayIndex(tok, len(p), false)
+			if err != nil {
+				return parent, err
+			}
+			removed = p[index]
+			return append(p[:index:index], p[index+1:]...), nil
+		}
+		return parent, fmt.Errorf("无法在非容器值中移除 %q", tok)
+	})
+	return doc, removed, err
+}
+
+// applyMergePatch 按 RFC 7386 把合并补丁应用到文档的副本上
+func applyMergePatch(doc, patch interface{}) interface{} {
+	return mergeInto(deepCopy(doc), patch)
+}
+
+// mergeInto 把合并补丁合并到 target 中并返回结果，target 会被直接修改
+func mergeInto(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return deepCopy(patch)
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{}, len(p))
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergeInto(t[k], v)
+		}
+	}
+	return t
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// assertSameJSON 检查两个JSON字符串在语义上相同
+func assertSameJSON(t *testing.T, actual, expected string) {
+	t.Helper()
+	var a, e interface{}
+	if err := json.Unmarshal([]byte(actual), &a); err != nil {
+		t.Fatalf("解析实际结果%s时出错: %v", actual, err)
+	}
+	if err := json.Unmarshal([]byte(expected), &e); err != nil {
+		t.Fatalf("解析预期结果%s时出错: %v", expected, err)
+	}
+	if !reflect.DeepEqual(a, e) {
+		t.Errorf("实际结果为%s，预期%s", actual, expected)
+	}
+}
+
+// TestApplyPatch_Operations 测试各类补丁操作
+func TestApplyPatch_Operations(t *testing.T) {
+	service := NewJSONDiffService()
+	testCases := []struct {
+		desc     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"添加对象成员", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"在数组中插入", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"追加到数组末尾", `{"foo":[1]}`, `[{"op":"add","path":"/foo/-","value":[2]}]`, `{"foo":[1,[2]]}`},
+		{"移除对象成员", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"移除数组元素", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"替换值", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"移动值", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"移动数组元素", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"复制值", `{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/b/-","value":2}]`, `{"a":{"b":[1]},"c":{"b":[1,2]}}`},
+		{"校验通过", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"添加null值", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":null}]`, `{"foo":"bar","child":null}`},
+		{"转义的键名", `{"a/b":1,"m~n":2}`, `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`, `{"a/b":3}`},
+		{"替换整个文档", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := service.ApplyPatch(tc.doc, tc.patch)
+			if err != nil {
+				t.Fatalf("应用补丁时出错: %v", err)
+			}
+			assertSameJSON(t, result, tc.expected)
+		})
+	}
+}
+
+// TestApplyPatch_Errors 测试补丁操作失败时返回原文档并标明失败的操作
+func TestApplyPatch_Errors(t *testing.T) {
+	service := NewJSONDiffService()
+	doc := `{"foo":"bar","list":[1,2]}`
+	testCases := []struct {
+		desc  string
+		patch string
+		index int
+		path  string
+	}{
+		{"校验失败", `[{"op":"add","path":"/x","value":1},{"op":"test","path":"/foo","value":"baz"}]`, 1, "/foo"},
+		{"路径不存在", `[{"op":"remove","path":"/missing"}]`, 0, "/missing"},
+		{"父路径不存在", `[{"op":"add","path":"/a/b","value":1}]`, 0, "/a/b"},
+		{"数组下标越界", `[{"op":"add","path":"/list/3","value":1}]`, 0, "/list/3"},
+		{"数组下标有前导零", `[{"op":"replace","path":"/list/01","value":1}]`, 0, "/list/01"},
+		{"移动到自身内部", `[{"op":"move","from":"/list","path":"/list/0"}]`, 0, "/list/0"},
+		{"缺少value成员", `[{"op":"add","path":"/x"}]`, 0, "/x"},
+		{"未知操作", `[{"op":"merge","path":"/x"}]`, 0, "/x"},
+		{"无效的转义", `[{"op":"remove","path":"/a~2"}]`, 0, "/a~2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			result, err := service.ApplyPatch(doc, tc.patch)
+			if err == nil {
+				t.Fatalf("预期应用补丁失败，但实际得到%s", result)
+			}
+			var patchErr *PatchError
+			if !errors.As(err, &patchErr) {
+				t.Fatalf("预期返回*PatchError，但实际为%T: %v", err, err)
+			}
+			if patchErr.Index != tc.index || patchErr.Path != tc.path {
+				t.Errorf("失败操作为%d（%s），预期%d（%s）", patchErr.Index, patchErr.Path, tc.index, tc.path)
+			}
+			if result != doc {
+				t.Errorf("补丁失败时应返回原文档，但实际为%s", result)
+			}
+		})
+	}
+}
+
+// TestApplyPatch_Atomic 测试补丁失败时之前的操作不会修改文档
+func TestApplyPatch_Atomic(t *testing.T) {
+	doc := map[string]interface{}{"a": []interface{}{1.0, 2.0}}
+	patch := JSONPatch{
+		{Op: PatchRemove, Path: "/a/0"},
+		{Op: PatchAdd, Path: "/b", Value: "x"},
+		{Op: PatchTest, Path: "/b", Value: "y"},
+	}
+
+	result, err := applyPatch(doc, patch)
+	if err == nil {
+		t.Fatalf("预期应用补丁失败，但实际得到%v", result)
+	}
+	expected := map[string]interface{}{"a": []interface{}{1.0, 2.0}}
+	if !reflect.DeepEqual(doc, expected) || !reflect.DeepEqual(result, expected) {
+		t.Errorf("补丁失败后文档被修改为%v", doc)
+	}
+}
+
+// TestApplyPatch_RoundTrip 测试把生成的补丁应用到第一个JSON上得到第二个JSON
+func TestApplyPatch_RoundTrip(t *testing.T) {
+	pairs := [][2]string{
+		{`{"name":"Alice","age":30,"address":{"city":"New York"},"hobbies":["reading","swimming"]}`,
+			`{"name":"Alice","age":31,"address":{"city":"Boston","zip":"02108"},"hobbies":["reading","cycling"],"email":"alice@example.com"}`},
+		{`{"a":[1,2,3,4,5,6]}`, `{"a":[6,5,4,3,2,1]}`},
+		{`{"a":[1,2,3,4,5,6]}`, `{"a":[0,2,7,4,1,6,8,3]}`},
+		{`{"a":[{"id":1,"v":[1,2]},{"id":2,"v":[3]},{"id":3}]}`, `{"a":[{"id":3,"x":1},{"id":4},{"id":1,"v":[2,1]}]}`},
+		{`{"a":[[1,2],[3,4]],"b":{"c":{"d":[1]}}}`, `{"a":[[4,3],[2],[1]],"b":{"e":{"d":[1]}},"c":{"d":[1]}}`},
+		{`{"a":["x","x","y","x"]}`, `{"a":["y","x","z","x","x","x"]}`},
+		{`{"a/b":{"~":[null,true]}}`, `{"a/b":{"~":[true,null,false]},"":""}`},
+		{`[1,{"a":2}]`, `{"a":2}`},
+		{`{"tpl":{"k":[1,2]},"list":[{"k":[1,2]}]}`, `{"tpl":{"k":[1,2]},"list":[],"copy":{"k":[1,2]}}`},
+	}
+	optionSets := map[string][]Option{
+		"按下标":     nil,
+		"LCS":     {WithArrayDiffMode(ArrayDiffLCS)},
+		"按标识字段":   {WithArrayKey("a[*]", "id")},
+		"多重集合":    {WithUnorderedArrays("a", "a[*]")},
+		"带test操作": {WithArrayDiffMode(ArrayDiffLCS), WithPatchTests()},
+	}
+
+	for name, opts := range optionSets {
+		service := NewJSONDiffService(opts...)
+		for _, pair := range pairs {
+			patch, err := service.CreatePatch(pair[0], pair[1])
+			if err != nil {
+				t.Fatalf("[%s] 生成补丁时出错: %v", name, err)
+			}
+			result, err := service.ApplyPatch(pair[0], patchString(t, patch))
+			if err != nil {
+				t.Errorf("[%s] 应用补丁%s时出错: %v", name, patchString(t, patch), err)
+				continue
+			}
+			assertSameJSON(t, result, pair[1])
+		}
+	}
+}
+
+// TestApplyMergePatch 测试 RFC 7386 中的合并补丁示例
+func TestApplyMergePatch(t *testing.T) {
+	service := NewJSONDiffService()
+	testCases := []struct {
+		doc      string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tc := range testCases {
+		result, err := service.ApplyMergePatch(tc.doc, tc.patch)
+		if err != nil {
+			t.Errorf("应用合并补丁%s时出错: %v", tc.patch, err)
+			continue
+		}
+		assertSameJSON(t, result, tc.expected)
+	}
+
+	if _, err := service.ApplyMergePatch(`{}`, `{`); err == nil {
+		t.Errorf("预期合并补丁无效时出错，但实际没有")
+	}
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// patchString 把补丁序列化为字符串，便于比较
+func patchString(t *testing.T, patch JSONPatch) string {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatalf("序列化补丁时出错: %v", err)
+	}
+	return string(data)
+}
+
+// TestCreatePatch_Operations 测试生成的补丁操作
+func TestCreatePatch_Operations(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		opts     []Option
+		json1    string
+		json2    string
+		expected string
+	}{
+		{
+			desc:     "相同的JSON",
+			json1:    `{"a":1}`,
+			json2:    `{"a":1}`,
+			expected: `[]`,
+		},
+		{
+			desc:     "对象成员的增删改",
+			json1:    `{"name":"Alice","age":30,"zip":"10001"}`,
+			json2:    `{"name":"Alice","age":31,"email":null}`,
+			expected: `[{"op":"replace","path":"/age","value":31},{"op":"remove","path":"/zip"},{"op":"add","path":"/email","value":null}]`,
+		},
+		{
+			desc:     "转义特殊键名",
+			json1:    `{"a/b":1,"m~n":{"x":1}}`,
+			json2:    `{"a/b":2,"m~n":{"x":2}}`,
+			expected: `[{"op":"replace","path":"/a~1b","value":2},{"op":"replace","path":"/m~0n/x","value":2}]`,
+		},
+		{
+			desc:     "类型变化时整体替换",
+			json1:    `{"a":{"x":1}}`,
+			json2:    `{"a":[1]}`,
+			expected: `[{"op":"replace","path":"/a","value":[1]}]`,
+		},
+		{
+			desc:     "替换整个文档",
+			json1:    `[1]`,
+			json2:    `"x"`,
+			expected: `[{"op":"replace","path":"","value":"x"}]`,
+		},
+		{
+			desc:     "按下标比较数组时从后向前移除",
+			json1:    `{"a":[1,2,3,4]}`,
+			json2:    `{"a":[1,5]}`,
+			expected: `[{"op":"replace","path":"/a/1","value":5},{"op":"remove","path":"/a/3"},{"op":"remove","path":"/a/2"}]`,
+		},
+		{
+			desc:     "LCS模式在头部插入",
+			opts:     []Option{WithArrayDiffMode(ArrayDiffLCS)},
+			json1:    `{"a":["x","y"]}`,
+			json2:    `{"a":["w","x","y"]}`,
+			expected: `[{"op":"add","path":"/a/0","value":"w"}]`,
+		},
+		{
+			desc:     "重新排序的元素生成移动",
+			opts:     []Option{WithArrayDiffMode(ArrayDiffLCS)},
+			json1:    `{"a":["x","y","z"]}`,
+			json2:    `{"a":["z","x","y"]}`,
+			expected: `[{"op":"move","from":"/a/2","path":"/a/0"}]`,
+		},
+		{
+			desc:     "按标识字段配对后修改并调整顺序",
+			opts:     []Option{WithArrayKey("a[*]", "id")},
+			json1:    `{"a":[{"id":1,"v":"x"},{"id":2,"v":"y"}]}`,
+			json2:    `{"a":[{"id":2,"v":"z"},{"id":1,"v":"x"}]}`,
+			expected: `[{"op":"replace","path":"/a/1/v","value":"z"},{"op":"move","from":"/a/1","path":"/a/0"}]`,
+		},
+		{
+			desc:     "从未修改的成员复制",
+			json1:    `{"tpl":{"k":[1,2]}}`,
+			json2:    `{"tpl":{"k":[1,2]},"copy":{"k":[1,2]}}`,
+			expected: `[{"op":"copy","from":"/tpl","path":"/copy"}]`,
+		},
+		{
+			desc:     "来源被修改时不复制",
+			json1:    `{"tpl":{"k":[1,2]}}`,
+			json2:    `{"tpl":{"k":[1,3]},"copy":{"k":[1,2]}}`,
+			expected: `[{"op":"replace","path":"/tpl/k/1","value":3},{"op":"add","path":"/copy","value":{"k":[1,2]}}]`,
+		},
+		{
+			desc:     "在移除和替换之前校验旧值",
+			opts:     []Option{WithPatchTests()},
+			json1:    `{"a":1,"b":[true]}`,
+			json2:    `{"a":2}`,
+			expected: `[{"op":"test","path":"/a","value":1},{"op":"replace","path":"/a","value":2},{"op":"test","path":"/b","value":[true]},{"op":"remove","path":"/b"}]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			patch, err := NewJSONDiffService(tc.opts...).CreatePatch(tc.json1, tc.json2)
+			if err != nil {
+				t.Fatalf("生成补丁时出错: %v", err)
+			}
+			if got := patchString(t, patch); got != tc.expected {
+				t.Errorf("生成的补丁为%s，预期%s", got, tc.expected)
+			}
+		})
+	}
+}
+
+// TestCreatePatch_InvalidJSON 测试生成补丁时输入无效的JSON
+func TestCreatePatch_InvalidJSON(t *testing.T) {
+	service := NewJSONDiffService()
+	if _, err := service.CreatePatch(`{"a":`, `{}`); err == nil {
+		t.Errorf("预期第一个JSON无效时出错，但实际没有")
+	}
+	if _, err := service.CreatePatch(`{}`, `[`); err == nil {
+		t.Errorf("预期第二个JSON无效时出错，但实际没有")
+	}
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathFormat 表示差异结果和路径模式中使用的路径格式
+type PathFormat int
+
+const (
+	// PathDotted 点号格式（默认），例如 a.b[0].c；包含 . [ ] " * 的键和空键写成带引号的形式，例如 ["a.b"]。
+	// 路径模式中 [*] 匹配任意数组下标，* 匹配任意一个对象键，x-* 这样的键匹配符合通配的对象键，
+	// ** 匹配任意层级（包括零层）的路径段；带引号的键按字面匹配
+	PathDotted PathFormat = iota
+	// PathPointer RFC 6901 JSON Pointer 格式，例如 /a~1b/0/c；
+	// 路径模式中 * 匹配任意一个对象键或数组下标，x-* 匹配符合通配的一段，** 匹配任意层级的路径段
+	PathPointer
+)
+
+// WithPathFormat 设置路径格式，差异结果中的路径、忽略路径以及数组规则的路径模式都使用该格式
+func WithPathFormat(format PathFormat) Option {
+	return func(c *diffConfig) {
+		c.pathFormat = format
+	}
+}
+
+// key 构建对象成员的路径
+func (f PathFormat) key(parent, key string) string {
+	if f == PathPointer {
+		return pointerKey(parent, key)
+	}
+	if needsQuote(key) {
+		return parent + "[" + quoteKey(key) + "]"
+	}
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// index 构建数组元素的路径
+func (f PathFormat) index(parent string, i int) string {
+	if f == PathPointer {
+		return pointerIndex(parent, i)
+	}
+	return parent + "[" + strconv.Itoa(i) + "]"
+}
+
+// needsQuote 判断点号格式中的键是否需要写成带引号的形式
+func needsQuote(key string) bool {
+	return key == "" || strings.ContainsAny(key, `.[]"*`)
+}
+
+// quoteKey 把键编码为JSON字符串
+func quoteKey(key string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(key); err != nil {
+		return strconv.Quote(key)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// pathSegment 表示路径中的一段
+type pathSegment struct {
+	key       string // 对象成员的键或数组下标的文本
+	isIndex   bool   // 是否为数组下标
+	wildcard  bool   // 是否为匹配任意数组下标的通配符
+	glob      bool   // 模式中包含 * 的键，按通配匹配
+	recursive bool   // 模式中的 **，匹配任意层级的路径段
+}
+
+// segments 把路径或路径模式拆分为路径段
+func (f PathFormat) segments(path string) ([]pathSegment, error) {
+	if f == PathPointer {
+		tokens, err := parsePointer(path)
+		if err != nil {
+			return nil, err
+		}
+		segs := make([]pathSegment, len(tokens))
+		for i, tok := range tokens {
+			segs[i] = pointerSegment(tok)
+		}
+		return segs, nil
+	}
+	return parseDottedPath(path)
+}
+
+// pointerSegment 把 JSON Pointer 的引用标记转换为路径段，无法区分对象键和数组下标，能作为下标的都视为下标
+func pointerSegment(tok string) pathSegment {
+	_, err := parseArrayIndex(tok, int(^uint(0)>>1), false)
+	return pathSegment{
+		key:       tok,
+		isIndex:   err == nil,
+		wildcard:  tok == "*",
+		glob:      strings.Contains(tok, "*"),
+		recursive: tok == "**",
+	}
+}
+
+// keySegment 返回对象成员对应的路径段，与解析 f.key 构建的路径得到的路径段相同
+func (f PathFormat) keySegment(key string) pathSegment {
+	if f == PathPointer {
+		return pointerSegment(key)
+	}
+	return pathSegment{key: key}
+}
+
+// indexSegment 返回数组元素对应的路径段
+func (f PathFormat) indexSegment(i int) pathSegment {
+	return pathSegment{key: strconv.Itoa(i), isIndex: true}
+}
+
+// parseDottedPath 解析点号格式的路径，支持 [n]、[*] 和 ["key"] 三种方括号形式
+func parseDottedPath(path string) ([]pathSegment, error) {
+	var segs []pathSegment
+	for i := 0; i < len(path); {
+		if path[i] == '[' {
+			seg, n, err := parseBracket(path[i:])
+			if err != nil {
+				return nil, fmt.Errorf("无效的路径 %q: %v", path, err)
+			}
+			segs = append(segs, seg)
+			i += n
+			continue
+		}
+
+		// 除第一段外，键之前必须有 .
+		if len(segs) > 0 {
+			if path[i] != '.' {
+				return nil, fmt.Errorf("无效的路径 %q: 位置 %d 缺少 .", path, i)
+			}
+			i++
+		}
+		end := strings.IndexAny(path[i:], ".[")
+		if end < 0 {
+			end = len(path) - i
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("无效的路径 %q: 位置 %d 的键为空", path, i)
+		}
+		key := path[i : i+end]
+		segs = append(segs, pathSegment{key: key, glob: strings.Contains(key, "*"), recursive: key == "**"})
+		i += end
+	}
+	return segs, nil
+}
+
+// parseBracket 解析以 [ 开头的路径段，返回路径段和消耗的字节数
+func parseBracket(s string) (pathSegment, int, error) {
+	if strings.HasPrefix(s, `["`) {
+		// 带引号的键中可能包含 ]，按JSON字符串读取到结束引号为止
+		dec := json.NewDecoder(strings.NewReader(s[1:]))
+		var key string
+		if err := dec.Decode(&key); err != nil {
+			return pathSegment{}, 0, fmt.Errorf("无效的键: %v", err)
+		}
+		n := 1 + int(dec.InputOffset())
+		if n >= len(s) || s[n] != ']' {
+			return pathSegment{}, 0, fmt.Errorf("键之后缺少 ]")
+		}
+		return pathSegment{key: key}, n + 1, nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return pathSegment{}, 0, fmt.Errorf("缺少 ]")
+	}
+	inner := s[1:end]
+	if inner == "*" {
+		return pathSegment{key: inner, isIndex: true, wildcard: true}, end + 1, nil
+	}
+	if inner == "" || strings.Trim(inner, "0123456789") != "" {
+		return pathSegment{}, 0, fmt.Errorf("无效的数组下标 %q", inner)
+	}
+	return pathSegment{key: inner, isIndex: true}, end + 1, nil
+}
+
+// matches 检查路径是否匹配模式，模式中可以使用数组下标、对象键和任意层级的通配符；模式无法解析时只做精确匹配
+func (f PathFormat) matches(path, pattern string) bool {
+	if path == pattern {
+		return true
+	}
+	patternSegs, err := f.segments(pattern)
+	if err != nil {
+		return false
+	}
+	pathSegs, err := f.segments(path)
+	if err != nil {
+		return false
+	}
+	return f.matchSegments(pathSegs, patternSegs)
+}
+
+// matchSegments 逐段匹配路径和模式，** 依次尝试匹配零个或多个路径段
+func (f PathFormat) matchSegments(segs, pattern []pathSegment) bool {
+	for len(pattern) > 0 {
+		p := pattern[0]
+		if p.recursive {
+			// 连续的 ** 与单个 ** 等价
+			for len(pattern) > 1 && pattern[1].recursive {
+				pattern = pattern[1:]
+			}
+			for i := 0; i <= len(segs); i++ {
+				if f.matchSegments(segs[i:], pattern[1:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 || !f.matchSegment(segs[0], p) {
+			return false
+		}
+		segs, pattern = segs[1:], pattern[1:]
+	}
+	return len(segs) == 0
+}
+
+// matchSegment 检查单个路径段是否匹配模式段
+func (f PathFormat) matchSegment(s, p pathSegment) bool {
+	switch {
+	case p.wildcard && s.isIndex:
+		return true
+	case p.glob:
+		// 点号格式中键的通配不匹配数组下标，JSON Pointer 无法区分两者
+		return (f == PathPointer || !s.isIndex) && globMatch(p.key, s.key)
+	case p.wildcard:
+		return false
+	}
+	return p.key == s.key && p.isIndex == s.isIndex
+}
+
+// globMatch 检查字符串是否匹配通配模式，* 匹配任意长度（包括零个）的字符
+func globMatch(pattern, s string) bool {
+	star, match := -1, 0
+	for i, j := 0, 0; j < len(s); {
+		switch {
+		case i < len(pattern) && pattern[i] == '*':
+			star, match = i, j
+			i++
+		case i < len(pattern) && pattern[i] == s[j]:
+			i++
+			j++
+		case star >= 0:
+			// 回到上一个 * 处，让它多匹配一个字符
+			i, match = star+1, match+1
+			j = match
+		default:
+			return false
+		}
+		if j == len(s) {
+			return strings.Trim(pattern[i:], "*") == ""
+		}
+	}
+	return strings.Trim(pattern, "*") == ""
+}
+
+// matchesAny 检查路径是否匹配任一模式
+func (f PathFormat) matchesAny(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if f.matches(path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDescendant 判断 path 是否位于 ancestor 之下
+func (f PathFormat) isDescendant(path, ancestor string) bool {
+	if !strings.HasPrefix(path, ancestor) || len(path) == len(ancestor) {
+		return false
+	}
+	next := path[len(ancestor)]
+	if f == PathPointer {
+		return next == '/'
+	}
+	return next == '.' || next == '['
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCompareJSON_QuotedKeys 测试点号格式中包含特殊字符的键写成带引号的形式
+func TestCompareJSON_QuotedKeys(t *testing.T) {
+	service := NewJSONDiffService()
+	json1 := `{"a.b":1,"a":{"b":1},"x[0]":1,"":1,"n":{"c.d":[1]},"q\"]":1}`
+	json2 := `{"a.b":2,"a":{"b":2},"x[0]":2,"":2,"n":{"c.d":[2]},"q\"]":2}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	for _, path := range []string{`["a.b"]`, `a.b`, `["x[0]"]`, `[""]`, `n["c.d"][0]`, `["q\"]"]`} {
+		if _, exists := diff.Changed[path]; !exists {
+			t.Errorf("预期%s被检测到变更，实际变更为%v", path, diff.Changed)
+		}
+	}
+	if len(diff.Changed) != 6 {
+		t.Errorf("预期6处变更，实际为%v", diff.Changed)
+	}
+
+	// 忽略路径区分键 "a.b" 和嵌套路径 a.b
+	diff, err = service.CompareJSONWithIgnore(json1, json2, []string{`["a.b"]`, `n["c.d"][*]`})
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	for _, path := range []string{`["a.b"]`, `n["c.d"][0]`} {
+		if _, exists := diff.Changed[path]; exists {
+			t.Errorf("%s应该被忽略", path)
+		}
+	}
+	if _, exists := diff.Changed["a.b"]; !exists {
+		t.Errorf("嵌套路径a.b不应被忽略")
+	}
+}
+
+// TestCompareJSON_PointerFormat 测试使用 JSON Pointer 格式的路径和忽略路径
+func TestCompareJSON_PointerFormat(t *testing.T) {
+	service := NewJSONDiffService(WithPathFormat(PathPointer), WithArrayKey("/items/*", "id"))
+	json1 := `{"a/b":1,"m~n":{"x":1},"list":[1,2],"items":[{"id":1,"v":1},{"id":2,"v":1}],"old":true}`
+	json2 := `{"a/b":2,"m~n":{"x":2},"list":[1,3,4],"items":[{"id":2,"v":2},{"id":1,"v":1}],"new":true}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	for _, path := range []string{"/a~1b", "/m~0n/x", "/list/1", "/items/0/v"} {
+		if _, exists := diff.Changed[path]; !exists {
+			t.Errorf("预期%s被检测到变更，实际变更为%v", path, diff.Changed)
+		}
+	}
+	if !reflect.DeepEqual(diff.Added, []string{"/list/2", "/new"}) && !reflect.DeepEqual(diff.Added, []string{"/new", "/list/2"}) {
+		t.Errorf("新增路径为%v，预期/list/2和/new", diff.Added)
+	}
+	if !reflect.DeepEqual(diff.Removed, []string{"/old"}) {
+		t.Errorf("移除路径为%v，预期/old", diff.Removed)
+	}
+
+	diff, err = service.CompareJSONWithIgnore(json1, json2, []string{"/a~1b", "/list/*", "/items/*/v"})
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	for _, path := range []string{"/a~1b", "/list/1", "/items/0/v"} {
+		if _, exists := diff.Changed[path]; exists {
+			t.Errorf("%s应该被忽略", path)
+		}
+	}
+	if _, exists := diff.Changed["/m~0n/x"]; !exists {
+		t.Errorf("/m~0n/x不应被忽略")
+	}
+}
+
+// TestPathFormat_Segments 测试生成的路径可以解析回原来的键和下标
+func TestPathFormat_Segments(t *testing.T) {
+	keys := []string{"plain", "a.b", "x[0]", "", `q"]`, "a/b", "m~n", "*", "0"}
+	for _, f := range []PathFormat{PathDotted, PathPointer} {
+		for _, k := range keys {
+			path := f.index(f.key(f.key("", "root"), k), 3)
+			segs, err := f.segments(path)
+			if err != nil {
+				t.Errorf("解析路径%q时出错: %v", path, err)
+				continue
+			}
+			if len(segs) != 3 || segs[0].key != "root" || segs[1].key != k || segs[2].key != "3" || !segs[2].isIndex {
+				t.Errorf("路径%q解析为%+v", path, segs)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"a..b", "a[", "a[x]", `a["b"`, ".a"} {
+		if _, err := PathDotted.segments(invalid); err == nil {
+			t.Errorf("预期解析路径%q时出错，但实际没有", invalid)
+		}
+	}
+}
+
+// TestPathFormat_Matches 测试两种格式下的路径匹配
+func TestPathFormat_Matches(t *testing.T) {
+	testCases := []struct {
+		format   PathFormat
+		path     string
+		pattern  string
+		expected bool
+	}{
+		{PathDotted, `users[0]["a.b"]`, `users[*]["a.b"]`, true},
+		{PathDotted, `users[0].a.b`, `users[*]["a.b"]`, false},
+		{PathDotted, `users.0`, `users[*]`, false},
+		{PathDotted, `users["x"]`, `users.x`, true},
+		{PathPointer, "/users/0/name", "/users/*/name", true},
+		{PathPointer, "/users/x/name", "/users/*/name", true},
+		{PathPointer, "/users/x/name", "/users/*", false},
+		{PathPointer, "/users/*", "/users/*", true},
+		{PathPointer, "/a~1b", "/a/b", false},
+	}
+
+	for _, tc := range testCases {
+		if actual := tc.format.matches(tc.path, tc.pattern); actual != tc.expected {
+			t.Errorf("格式%d下matches(%q, %q) = %v，预期%v", tc.format, tc.path, tc.pattern, actual, tc.expected)
+		}
+	}
+}
+
+// TestMatchesPath_KeyAndRecursiveWildcards 测试对象键通配、键的通配片段和任意层级通配
+func TestMatchesPath_KeyAndRecursiveWildcards(t *testing.T) {
+	testCases := []struct {
+		path     string
+		pattern  string
+		expected bool
+	}{
+		{"users.alice.updatedAt", "users.*.updatedAt", true},
+		{"users[0].updatedAt", "users.*.updatedAt", false},
+		{"users.alice.profile.updatedAt", "users.*.updatedAt", false},
+		{"metadata.updated", "**.metadata.updated", true},
+		{"a.b[2].metadata.updated", "**.metadata.updated", true},
+		{"a.metadata.updated.x", "**.metadata.updated", false},
+		{"a.b.c", "a.**", true},
+		{"a", "a.**", true},
+		{"a[1].x.c", "a.**.c", true},
+		{"created_at", "*_at", true},
+		{"order.items[3].shipped_at", "**.*_at", true},
+		{"order.items[3].shipped_on", "**.*_at", false},
+		{"headers.x-request-id", "headers.x-*", true},
+		{"headers.X-Request-Id", "headers.x-*", false},
+		{`["*"]`, `["*"]`, true},
+		{"star", `["*"]`, false},
+		{"a.b.c.d", "**.**.d", true},
+		{"users[0].name", "users[*].name", true},
+	}
+
+	for _, tc := range testCases {
+		if actual := matchesPath(tc.path, tc.pattern); actual != tc.expected {
+			t.Errorf("matchesPath(%q, %q) = %v，预期%v", tc.path, tc.pattern, actual, tc.expected)
+		}
+	}
+
+	pointerCases := []struct {
+		path     string
+		pattern  string
+		expected bool
+	}{
+		{"/a/b/metadata/updated", "/**/metadata/updated", true},
+		{"/items/3/created_at", "/items/*/*_at", true},
+		{"/items/3/created", "/items/*/*_at", false},
+	}
+	for _, tc := range pointerCases {
+		if actual := PathPointer.matches(tc.path, tc.pattern); actual != tc.expected {
+			t.Errorf("PathPointer.matches(%q, %q) = %v，预期%v", tc.path, tc.pattern, actual, tc.expected)
+		}
+	}
+}
+
+// TestCompareJSONWithIgnore_RecursiveWildcards 测试在任意位置忽略易变字段
+func TestCompareJSONWithIgnore_RecursiveWildcards(t *testing.T) {
+	service := NewJSONDiffService()
+	json1 := `{"id":1,"updated_at":"t1","users":{"a":{"name":"A","updated_at":"t1"}},"list":[{"meta":{"x-trace":"1"},"v":1}]}`
+	json2 := `{"id":2,"updated_at":"t2","users":{"a":{"name":"B","updated_at":"t2"}},"list":[{"meta":{"x-trace":"2"},"v":2}]}`
+
+	diff, err := service.CompareJSONWithIgnore(json1, json2, []string{"**.*_at", "**.x-*"})
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	expected := []string{"id", "users.a.name", "list[0].v"}
+	if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("变更路径为%v，预期%v", paths, expected)
+	}
+}
+
+// TestGlobMatch 测试键的通配匹配
+func TestGlobMatch(t *testing.T) {
+	testCases := []struct {
+		pattern  string
+		s        string
+		expected bool
+	}{
+		{"*", "", true},
+		{"*", "abc", true},
+		{"a*", "abc", true},
+		{"*c", "abc", true},
+		{"a*c", "abbbc", true},
+		{"a*b*c", "aXbYbZc", true},
+		{"*ab", "abab", true},
+		{"a*c", "abcd", false},
+		{"abc", "abd", false},
+		{"", "", true},
+		{"", "a", false},
+		{"**a", "a", true},
+	}
+	for _, tc := range testCases {
+		if actual := globMatch(tc.pattern, tc.s); actual != tc.expected {
+			t.Errorf("globMatch(%q, %q) = %v，预期%v", tc.pattern, tc.s, actual, tc.expected)
+		}
+	}
+}
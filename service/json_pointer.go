@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pointerEscaper 按 RFC 6901 转义 JSON Pointer 中的引用标记
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// pointerKey 构建对象成员的 JSON Pointer
+func pointerKey(parent, key string) string {
+	return parent + "/" + pointerEscaper.Replace(key)
+}
+
+// pointerIndex 构建数组元素的 JSON Pointer
+func pointerIndex(parent string, index int) string {
+	return parent + "/" + strconv.Itoa(index)
+}
+
+// parsePointer 把 JSON Pointer 解析为引用标记列表，空字符串表示整个文档
+func parsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("JSON Pointer 必须以 / 开头: %q", ptr)
+	}
+
+	tokens := strings.Split(ptr[1:], "/")
+	for i, tok := range tokens {
+		// 只允许 ~0 和 ~1 两种转义
+		for j := 0; j < len(tok); j++ {
+			if tok[j] == '~' && (j+1 >= len(tok) || (tok[j+1] != '0' && tok[j+1] != '1')) {
+				return nil, fmt.Errorf("JSON Pointer 包含无效的转义: %q", ptr)
+			}
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// parseArrayIndex 解析数组下标引用标记，下标不能有前导零；allowEnd 为 true 时允许 "-" 和等于长度的下标表示末尾
+func parseArrayIndex(tok string, length int, allowEnd bool) (int, error) {
+	if tok == "-" && allowEnd {
+		return length, nil
+	}
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("无效的数组下标: %q", tok)
+	}
+	for _, c := range tok {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("无效的数组下标: %q", tok)
+		}
+	}
+	index, err := strconv.Atoi(tok)
+	if err != nil {
+		return 0, fmt.Errorf("无效的数组下标: %q", tok)
+	}
+	limit := length
+	if allowEnd {
+		limit++
+	}
+	if index >= limit {
+		return 0, fmt.Errorf("数组下标越界: %d（长度 %d）", index, length)
+	}
+	return index, nil
+}
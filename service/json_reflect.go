@@ -0,0 +1,469 @@
+package service
+
+import (
+	"context"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompareValues 比较两个任意的 Go 值，按 encoding/json 的规则把值转换为JSON结构后比较，
+// 结果与把两个值分别用 json.Marshal 编码后调用 CompareJSON 相同，但不需要编码为文本再解析。
+// 支持结构体字段的 json 标签（改名、omitempty、- 和 string）、嵌入结构体的字段提升，
+// 以及实现了 json.Marshaler 或 encoding.TextMarshaler 的类型；对象成员按结构体字段的声明顺序比较
+func (s *jsonDiffServiceImpl) CompareValues(a, b interface{}, opts ...Option) (JSONDiffResult, error) {
+	config := s.config.with(opts)
+	order := config.newKeyOrder()
+	var objs [2]interface{}
+	for i, v := range []interface{}{a, b} {
+		e := &valueEncoder{config: &config, order: order}
+		obj, err := e.encode("", reflect.ValueOf(v), false)
+		if err != nil {
+			return newDiffResult(), fmt.Errorf("转换第%s个值失败: %v", ordinals[i], err)
+		}
+		objs[i] = obj
+	}
+	return config.compareTrees(context.Background(), objs[0], objs[1], order)
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// cycleCheckDepth 与 encoding/json 一样，嵌套超过这么多层的指针、映射和切片之后才开始检查循环引用
+const cycleCheckDepth = 1000
+
+// valueEncoder 把 Go 值转换为比较使用的JSON结构，转换规则与 json.Marshal 相同
+type valueEncoder struct {
+	config *diffConfig
+	order  keyOrder // 记录由结构体转换得到的对象的成员顺序，为 nil 时不记录
+
+	depth   int                  // 当前嵌套的指针、映射和切片层数
+	visited map[interface{}]bool // 嵌套过深时正在转换的指针、映射和切片，用于发现循环引用
+}
+
+// encode 转换一个值，path 用于错误信息，quoted 表示结构体字段带有 string 选项
+func (e *valueEncoder) encode(path string, v reflect.Value, quoted bool) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	// 与 json.Marshal 一样，可寻址的值也使用指针接收者上的方法
+	t := v.Type()
+	if t.Kind() != reflect.Ptr && v.CanAddr() &&
+		(reflect.PtrTo(t).Implements(marshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)) {
+		v, t = v.Addr(), v.Addr().Type()
+	}
+	if t.Implements(marshalerType) {
+		if isNilValue(v) {
+			return nil, nil
+		}
+		data, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("路径 %q 的 MarshalJSON 失败: %v", path, err)
+		}
+		obj, err := e.config.parseBytes(data, e.order)
+		if err != nil {
+			return nil, fmt.Errorf("路径 %q 的 MarshalJSON 返回了无效的JSON: %v", path, err)
+		}
+		return obj, nil
+	}
+	if t.Implements(textMarshalerType) {
+		if isNilValue(v) {
+			return nil, nil
+		}
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, fmt.Errorf("路径 %q 的 MarshalText 失败: %v", path, err)
+		}
+		return string(text), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if quoted {
+			return strconv.FormatBool(v.Bool()), nil
+		}
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.number(strconv.FormatInt(v.Int(), 10), quoted), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.number(strconv.FormatUint(v.Uint(), 10), quoted), nil
+	case reflect.Float32, reflect.Float64:
+		var f interface{} = v.Float()
+		if v.Kind() == reflect.Float32 {
+			f = float32(v.Float())
+		}
+		data, err := json.Marshal(f)
+		if err != nil {
+			return nil, fmt.Errorf("路径 %q 的数字无效: %v", path, err)
+		}
+		return e.number(string(data), quoted), nil
+	case reflect.String:
+		if quoted {
+			data, _ := json.Marshal(v.String())
+			return string(data), nil
+		}
+		return v.String(), nil
+	case reflect.Interface:
+		return e.encode(path, v.Elem(), false)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return e.nested(path, v, func() (interface{}, error) {
+			return e.encode(path, v.Elem(), quoted)
+		})
+	case reflect.Struct:
+		return e.encodeStruct(path, v)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return e.nested(path, v, func() (interface{}, error) {
+			return e.encodeMap(path, v)
+		})
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if isByteSlice(t) {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		return e.nested(path, v, func() (interface{}, error) {
+			return e.encodeArray(path, v)
+		})
+	case reflect.Array:
+		return e.encodeArray(path, v)
+	}
+	return nil, fmt.Errorf("路径 %q 的值类型 %s 无法转换为JSON", path, t)
+}
+
+// number 按配置的数字方式转换数字，quoted 为 true 时转换为字符串
+func (e *valueEncoder) number(text string, quoted bool) interface{} {
+	if quoted {
+		return text
+	}
+	switch e.config.numbers {
+	case NumberExact:
+		return canonicalNumber(json.Number(text))
+	case NumberLiteral:
+		return json.Number(text)
+	}
+	f, _ := strconv.ParseFloat(text, 64)
+	return f
+}
+
+// nested 转换指针、映射或切片指向的内容，嵌套过深时检查循环引用
+func (e *valueEncoder) nested(path string, v reflect.Value, fn func() (interface{}, error)) (interface{}, error) {
+	e.depth++
+	defer func() { e.depth-- }()
+	if e.depth <= cycleCheckDepth {
+		return fn()
+	}
+
+	// 切片以底层数组的地址和长度区分，与 encoding/json 相同
+	var key interface{} = v.Pointer()
+	if v.Kind() == reflect.Slice {
+		key = struct {
+			ptr uintptr
+			len int
+		}{v.Pointer(), v.Len()}
+	}
+	if e.visited == nil {
+		e.visited = make(map[interface{}]bool)
+	}
+	if e.visited[key] {
+		return nil, fmt.Errorf("路径 %q 存在循环引用", path)
+	}
+	e.visited[key] = true
+	defer delete(e.visited, key)
+	return fn()
+}
+
+// encodeStruct 按字段的 json 标签把结构体转换为对象
+func (e *valueEncoder) encodeStruct(path string, v reflect.Value) (interface{}, error) {
+	fields := cachedStructFields(v.Type())
+	obj := make(map[string]interface{}, len(fields))
+	keys := make([]string, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		child, err := e.encode(e.config.pathFormat.key(path, f.name), fv, f.quoted)
+		if err != nil {
+			return nil, err
+		}
+		obj[f.name] = child
+		keys = append(keys, f.name)
+	}
+	if e.order != nil {
+		e.order[reflect.ValueOf(obj).Pointer()] = keys
+	}
+	return obj, nil
+}
+
+// encodeMap 把映射转换为对象，键的转换规则与 json.Marshal 相同
+func (e *valueEncoder) encodeMap(path string, v reflect.Value) (interface{}, error) {
+	obj := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKeyName(iter.Key())
+		if err != nil {
+			return nil, fmt.Errorf("路径 %q 的映射键无效: %v", path, err)
+		}
+		child, err := e.encode(e.config.pathFormat.key(path, key), iter.Value(), false)
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = child
+	}
+	return obj, nil
+}
+
+// encodeArray 把切片或数组转换为数组
+func (e *valueEncoder) encodeArray(path string, v reflect.Value) (interface{}, error) {
+	arr := make([]interface{}, v.Len())
+	for i := range arr {
+		child, err := e.encode(e.config.pathFormat.index(path, i), v.Index(i), false)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = child
+	}
+	return arr, nil
+}
+
+// mapKeyName 返回映射键对应的对象键
+func mapKeyName(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Ptr && k.IsNil() {
+			return "", nil
+		}
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("不支持的键类型 %s", k.Type())
+}
+
+// isByteSlice 判断类型是否按 base64 字符串编码的字节切片
+func isByteSlice(t reflect.Type) bool {
+	if t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+	p := reflect.PtrTo(t.Elem())
+	return !p.Implements(marshalerType) && !p.Implements(textMarshalerType)
+}
+
+// isNilValue 判断值是否为 nil 指针或 nil 接口
+func isNilValue(v reflect.Value) bool {
+	return (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil()
+}
+
+// isEmptyValue 判断值在 omitempty 下是否被省略，规则与 json.Marshal 相同
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// fieldByIndex 按下标序列取出字段，经过的嵌入指针为 nil 时返回 false
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// structField 表示结构体中编码为对象成员的一个字段
+type structField struct {
+	name      string
+	index     []int // 字段的下标序列，嵌入结构体中的字段有多个下标
+	tagged    bool  // 名称来自 json 标签
+	omitEmpty bool
+	quoted    bool
+}
+
+// structFieldCache 缓存每个结构体类型的字段
+var structFieldCache sync.Map
+
+// cachedStructFields 返回结构体类型编码为对象成员的字段，按声明顺序排列
+func cachedStructFields(t reflect.Type) []structField {
+	if fields, ok := structFieldCache.Load(t); ok {
+		return fields.([]structField)
+	}
+	fields, _ := structFieldCache.LoadOrStore(t, typeFields(t))
+	return fields.([]structField)
+}
+
+// typeFields 按 encoding/json 的规则收集结构体的字段：逐层展开嵌入的结构体，
+// 同名字段中嵌套层数最少的胜出，层数相同时带标签的胜出，仍然无法区分时这些字段都被忽略
+func typeFields(t reflect.Type) []structField {
+	type pending struct {
+		typ   reflect.Type
+		index []int
+	}
+	var fields []structField
+	current, next := []pending{}, []pending{{typ: t}}
+	visited := map[reflect.Type]bool{}
+	// count 记录当前层中每个类型出现的次数，同一类型在同一层中嵌入多次时其字段相互冲突
+	count, nextCount := map[reflect.Type]int{}, map[reflect.Type]int{t: 1}
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+		for _, p := range current {
+			if visited[p.typ] {
+				continue
+			}
+			visited[p.typ] = true
+
+			for i := 0; i < p.typ.NumField(); i++ {
+				sf := p.typ.Field(i)
+				ft := sf.Type
+				if sf.Anonymous {
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts := parseJSONTag(tag)
+				index := append(append([]int(nil), p.index...), i)
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					// 没有名称的嵌入结构体，其字段留到下一层展开
+					nextCount[ft]++
+					if nextCount[ft] == 1 {
+						next = append(next, pending{typ: ft, index: index})
+					}
+					continue
+				}
+
+				f := structField{name: name, index: index, tagged: name != ""}
+				if f.name == "" {
+					f.name = sf.Name
+				}
+				for _, opt := range opts {
+					switch opt {
+					case "omitempty":
+						f.omitEmpty = true
+					case "string":
+						f.quoted = quotable(sf.Type)
+					}
+				}
+				fields = append(fields, f)
+				if count[p.typ] > 1 {
+					// 同一类型在同一层中嵌入多次，加入一个同名字段使其相互冲突
+					fields = append(fields, fields[len(fields)-1])
+				}
+			}
+		}
+	}
+
+	// 按名称、层数和是否带标签排序后选出每个名称的胜出字段
+	sort.SliceStable(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if len(a.index) != len(b.index) {
+			return len(a.index) < len(b.index)
+		}
+		return a.tagged && !b.tagged
+	})
+	out := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if dominant, ok := dominantField(fields[i:j]); ok {
+			out = append(out, dominant)
+		}
+		i = j
+	}
+
+	// 恢复声明顺序
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].index, out[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return out
+}
+
+// dominantField 从同名字段中选出胜出的字段，fields 已按层数和是否带标签排序
+func dominantField(fields []structField) (structField, bool) {
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) && fields[0].tagged == fields[1].tagged {
+		return structField{}, false
+	}
+	return fields[0], true
+}
+
+// parseJSONTag 拆分 json 标签中的名称和选项
+func parseJSONTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+// quotable 判断 string 选项是否适用于该类型的字段：布尔值、数字和字符串，以及指向它们的指针
+func quotable(t reflect.Type) bool {
+	if t.Name() == "" && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reflectAudit struct {
+	CreatedBy string    `json:"createdBy"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type reflectBase struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"` // 被外层带标签的同名字段覆盖
+}
+
+type reflectStatus int
+
+// MarshalJSON 把状态编码为名称
+func (s reflectStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string{"open", "closed"}[s])
+}
+
+type reflectLevel int
+
+// MarshalText 把级别编码为文本，指针接收者只在值可寻址时使用
+func (l *reflectLevel) MarshalText() ([]byte, error) {
+	return []byte(strings.Repeat("*", int(*l))), nil
+}
+
+type reflectOrder struct {
+	reflectBase
+	*reflectAudit
+	Name     string         `json:"title"`
+	Label    string         `json:"name"`
+	Status   reflectStatus  `json:"status"`
+	Level    reflectLevel   `json:"level"`
+	Total    int64          `json:"total,string"`
+	Price    *float64       `json:"price,omitempty"`
+	Note     string         `json:"note,omitempty"`
+	Secret   string         `json:"-"`
+	Dash     string         `json:"-,"`
+	Raw      []byte         `json:"raw"`
+	Tags     []string       `json:"tags"`
+	Counts   map[int]int    `json:"counts"`
+	Extra    interface{}    `json:"extra"`
+	Items    [2]reflectItem `json:"items"`
+	internal string
+}
+
+type reflectItem struct {
+	SKU string  `json:"sku"`
+	Qty float32 `json:"qty"`
+}
+
+// TestCompareValues_MatchesMarshal 测试直接比较 Go 值与先用 json.Marshal 编码再比较的结果相同
+func TestCompareValues_MatchesMarshal(t *testing.T) {
+	price := 9.5
+	a := reflectOrder{
+		reflectBase:  reflectBase{ID: 1, Name: "hidden"},
+		reflectAudit: &reflectAudit{CreatedBy: "alice", UpdatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		Name:         "order", Label: "first", Status: 0, Level: 1, Total: 1 << 60,
+		Secret: "a", Dash: "x", Raw: []byte("hi"), Tags: []string{"a"},
+		Counts: map[int]int{1: 1, 2: 2}, Extra: map[string]interface{}{"k": []int{1}},
+		Items: [2]reflectItem{{SKU: "x", Qty: 0.1}, {SKU: "y", Qty: 2}},
+	}
+	b := a
+	b.reflectAudit = &reflectAudit{CreatedBy: "bob", UpdatedAt: time.Date(2024, 1, 1, 8, 0, 0, 0, time.FixedZone("", 8*3600))}
+	b.Status, b.Level, b.Total = 1, 2, 1<<60+1
+	b.Price, b.Note, b.Secret, b.Dash = &price, "n", "b", "y"
+	b.Raw, b.Tags, b.Counts = []byte("ho"), nil, map[int]int{1: 1, 3: 3}
+	b.Extra = map[string]interface{}{"k": []int{1, 2}}
+	b.Items[0].Qty = 0.2
+	b.internal = "ignored"
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithNumberMode(NumberExact)},
+		{WithPathFormat(PathPointer), WithIgnorePaths("/updatedAt")},
+	} {
+		service := NewJSONDiffService(opts...)
+		// 指针使结构体可寻址，与 json.Marshal(&a) 的行为相同
+		actual, err := service.CompareValues(&a, &b)
+		if err != nil {
+			t.Fatalf("比较Go值时出错: %v", err)
+		}
+
+		json1, _ := json.Marshal(&a)
+		json2, _ := json.Marshal(&b)
+		expected, err := service.CompareJSON(string(json1), string(json2))
+		if err != nil {
+			t.Fatalf("比较JSON时出错: %v", err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("直接比较Go值的结果与编码后比较不同:\n%+v\n%+v", actual.Changes, expected.Changes)
+		}
+		if len(actual.Changes) == 0 {
+			t.Errorf("应该有差异")
+		}
+	}
+}
+
+// TestCompareValues_Embedding 测试嵌入结构体的字段提升与冲突规则
+func TestCompareValues_Embedding(t *testing.T) {
+	type A struct{ X, Y int }
+	type B struct {
+		X int
+		Z int `json:"Y"`
+	}
+	type C struct {
+		A
+		B
+	}
+	fields := cachedStructFields(reflect.TypeOf(C{}))
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	// 同层的 X 相互冲突被忽略，带标签的 Y 胜出
+	if !reflect.DeepEqual(names, []string{"Y"}) {
+		t.Errorf("字段为%v，预期[Y]", names)
+	}
+
+	diff, err := NewJSONDiffService().CompareValues(C{A{1, 1}, B{1, 1}}, C{A{2, 2}, B{2, 2}})
+	if err != nil {
+		t.Fatalf("比较Go值时出错: %v", err)
+	}
+	if paths := diff.ChangedPaths(); !reflect.DeepEqual(paths, []string{"Y"}) {
+		t.Errorf("变更路径为%v，预期[Y]", paths)
+	}
+}
+
+// TestCompareValues_Errors 测试无法转换为JSON的值返回错误
+func TestCompareValues_Errors(t *testing.T) {
+	service := NewJSONDiffService()
+	type cyclic struct {
+		Next *cyclic
+	}
+	loop := &cyclic{}
+	loop.Next = loop
+
+	for _, v := range []interface{}{
+		make(chan int),
+		map[[2]int]int{{1, 2}: 1},
+		struct{ F func() }{},
+		loop,
+	} {
+		if _, err := service.CompareValues(v, nil); err == nil {
+			t.Errorf("%T 无法转换为JSON，应该返回错误", v)
+		}
+	}
+
+	if diff, err := service.CompareValues(nil, (*reflectOrder)(nil)); err != nil || len(diff.Changes) != 0 {
+		t.Errorf("nil与nil指针都是null，应该相等，实际差异为%+v，错误为%v", diff.Changes, err)
+	}
+}
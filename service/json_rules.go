@@ -0,0 +1,403 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// RuleError 表示规则文件中的一处错误
+type RuleError struct {
+	Line int    // 错误所在的行号，从 1 开始
+	Msg  string // 错误信息
+}
+
+// Error 返回带有行号的错误信息
+func (e RuleError) Error() string {
+	return fmt.Sprintf("第 %d 行: %s", e.Line, e.Msg)
+}
+
+// RuleErrors 表示规则文件中的所有错误，按行号顺序排列
+type RuleErrors []RuleError
+
+// Error 把所有错误合并为一条信息，每处错误占一行
+func (e RuleErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// RuleFile 表示从规则文件中加载的命名规则集。规则文件可以是 JSON 或 YAML，例如：
+//
+//	rulesets:
+//	  orders:
+//	    ignore: ["**.updatedAt", "$.items[?(@.deleted==true)]"]
+//	    arrayKeys:
+//	      - {path: items, fields: [sku]}
+//	    tolerances:
+//	      - {path: "items[*].price", absolute: 0.01}
+//	    unordered: [tags]
+//
+// 每个规则集支持的键有 ignore、include、unordered、arrayKeys、tolerances、arrayMode（index 或 lcs）、
+// moves、patchTests、pathFormat（dotted 或 pointer）、numbers（float64、exact 或 literal）、
+// order（document 或 path）、detail（full 或 paths）、maxDocumentSize、maxDepth 和 maxChanges
+type RuleFile struct {
+	names []string
+	sets  map[string][]Option
+}
+
+// LoadRuleFile 读取并校验规则文件
+func LoadRuleFile(path string) (*RuleFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取规则文件失败: %v", err)
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("规则文件 %s 无效: %w", path, err)
+	}
+	return rules, nil
+}
+
+// ParseRules 解析并校验规则文件的内容，第一个非空白字符为 { 时按 JSON 解析，否则按 YAML 解析。
+// YAML 使用 gopkg.in/yaml.v3 解析，支持锚点、别名、合并键、多行字符串和多文档，
+// 布尔值也可以写作 yes、no、on、off；多个文档中的规则集合并使用，名称不能重复。
+// 内容无效时返回 RuleErrors，其中包含所有未知的键、类型错误和无法解析的路径模式及其行号
+func ParseRules(data []byte) (*RuleFile, error) {
+	docs, err := parseRuleDocuments(data)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ruleChecker{}
+	rules := &RuleFile{sets: make(map[string][]Option)}
+	for _, root := range docs {
+		if !c.expectKind(root, ruleMap, "规则文件") {
+			continue
+		}
+		for _, key := range root.keys {
+			node := root.fields[key]
+			if key != "rulesets" {
+				c.errorf(node.line, "未知的键 %q", key)
+				continue
+			}
+			if !c.expectKind(node, ruleMap, "rulesets") {
+				continue
+			}
+			for _, name := range node.keys {
+				if _, exists := rules.sets[name]; exists {
+					c.errorf(node.fields[name].line, "规则集 %q 重复定义", name)
+					continue
+				}
+				rules.names = append(rules.names, name)
+				rules.sets[name] = c.ruleSet(node.fields[name])
+			}
+		}
+	}
+	if len(c.errs) > 0 {
+		return nil, c.errs
+	}
+	return rules, nil
+}
+
+// Names 按文件中的顺序返回所有规则集的名称
+func (f *RuleFile) Names() []string {
+	return append([]string(nil), f.names...)
+}
+
+// Options 返回指定规则集对应的选项，可以传给 NewJSONDiffService 或 Compare
+func (f *RuleFile) Options(name string) ([]Option, error) {
+	opts, exists := f.sets[name]
+	if !exists {
+		return nil, fmt.Errorf("规则集 %q 不存在", name)
+	}
+	return append([]Option(nil), opts...), nil
+}
+
+// ruleChecker 校验规则文件并收集所有错误
+type ruleChecker struct {
+	errs RuleErrors
+}
+
+// errorf 记录一处错误
+func (c *ruleChecker) errorf(line int, format string, args ...interface{}) {
+	c.errs = append(c.errs, RuleError{Line: line, Msg: fmt.Sprintf(format, args...)})
+}
+
+// ruleKindNames 错误信息中使用的值的种类名称
+var ruleKindNames = map[ruleKind]string{ruleScalar: "标量", ruleMap: "映射", ruleList: "序列"}
+
+// expectKind 检查值的种类，不符合时记录错误
+func (c *ruleChecker) expectKind(n *ruleNode, kind ruleKind, what string) bool {
+	if n.kind != kind {
+		c.errorf(n.line, "%s 应为%s，实际为%s", what, ruleKindNames[kind], ruleKindNames[n.kind])
+		return false
+	}
+	return true
+}
+
+// str 读取字符串
+func (c *ruleChecker) str(n *ruleNode, what string) (string, bool) {
+	if s, ok := n.value.(string); ok && n.kind == ruleScalar {
+		return s, true
+	}
+	c.errorf(n.line, "%s 应为字符串", what)
+	return "", false
+}
+
+// number 读取非负数
+func (c *ruleChecker) number(n *ruleNode, what string) (float64, bool) {
+	if f, ok := n.value.(float64); ok && n.kind == ruleScalar && f >= 0 {
+		return f, true
+	}
+	c.errorf(n.line, "%s 应为非负数", what)
+	return 0, false
+}
+
+// integer 读取非负整数
+func (c *ruleChecker) integer(n *ruleNode, what string) (int, bool) {
+	if f, ok := n.value.(float64); ok && n.kind == ruleScalar && f >= 0 && f == math.Trunc(f) && f <= math.MaxInt32 {
+		return int(f), true
+	}
+	c.errorf(n.line, "%s 应为非负整数", what)
+	return 0, false
+}
+
+// boolean 读取布尔值，YAML 中的 yes、no、on、off 等写法也按布尔值读取
+func (c *ruleChecker) boolean(n *ruleNode, what string) (bool, bool) {
+	if b, ok := n.value.(bool); ok && n.kind == ruleScalar {
+		return b, true
+	}
+	var b bool
+	if n.kind == ruleScalar && n.yaml != nil && n.yaml.Decode(&b) == nil {
+		return b, true
+	}
+	c.errorf(n.line, "%s 应为 true 或 false", what)
+	return false, false
+}
+
+// enum 读取枚举值，names 按顺序列出允许的取值，返回取值的下标
+func (c *ruleChecker) enum(n *ruleNode, what string, names ...string) (int, bool) {
+	if s, ok := n.value.(string); ok && n.kind == ruleScalar {
+		for i, name := range names {
+			if s == name {
+				return i, true
+			}
+		}
+	}
+	c.errorf(n.line, "%s 的取值应为 %s 之一", what, strings.Join(names, "、"))
+	return 0, false
+}
+
+// stringList 读取字符串序列，也允许只写一个字符串
+func (c *ruleChecker) stringList(n *ruleNode, what string) []string {
+	if n.kind == ruleScalar {
+		if s, ok := c.str(n, what); ok {
+			return []string{s}
+		}
+		return nil
+	}
+	if !c.expectKind(n, ruleList, what) {
+		return nil
+	}
+	var out []string
+	for _, item := range n.items {
+		if s, ok := c.str(item, what+" 的元素"); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// pattern 检查路径模式能否按路径格式解析，allowJSONPath 为 true 时也接受 JSONPath 表达式
+func (c *ruleChecker) pattern(n *ruleNode, what string, f PathFormat, allowJSONPath bool) (string, bool) {
+	pattern, ok := c.str(n, what)
+	if !ok {
+		return "", false
+	}
+	if isJSONPath(pattern) {
+		if !allowJSONPath {
+			c.errorf(n.line, "%s 不支持 JSONPath 表达式 %q", what, pattern)
+			return "", false
+		}
+		if _, err := parseJSONPath(pattern); err != nil {
+			c.errorf(n.line, "%v", err)
+			return "", false
+		}
+		return pattern, true
+	}
+	if _, err := f.segments(pattern); err != nil {
+		c.errorf(n.line, "%s 中的路径模式无效: %v", what, err)
+		return "", false
+	}
+	return pattern, true
+}
+
+// patterns 读取路径模式序列
+func (c *ruleChecker) patterns(n *ruleNode, what string, f PathFormat, allowJSONPath bool) []string {
+	var out []string
+	items := []*ruleNode{n}
+	if n.kind == ruleList {
+		items = n.items
+	}
+	for _, item := range items {
+		if p, ok := c.pattern(item, what, f, allowJSONPath); ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// fields 检查值是映射并且只包含允许的键
+func (c *ruleChecker) fields(n *ruleNode, what string, allowed ...string) bool {
+	if !c.expectKind(n, ruleMap, what) {
+		return false
+	}
+	for _, key := range n.keys {
+		known := false
+		for _, a := range allowed {
+			known = known || key == a
+		}
+		if !known {
+			c.errorf(n.fields[key].line, "%s 中未知的键 %q", what, key)
+		}
+	}
+	return true
+}
+
+// ruleSet 校验一个规则集并转换为选项
+func (c *ruleChecker) ruleSet(n *ruleNode) []Option {
+	if !c.expectKind(n, ruleMap, "规则集") {
+		return nil
+	}
+
+	// 路径模式按规则集的路径格式校验，因此先读取路径格式
+	var opts []Option
+	format := PathDotted
+	if node, exists := n.fields["pathFormat"]; exists {
+		if v, ok := c.enum(node, "pathFormat", "dotted", "pointer"); ok {
+			format = PathFormat(v)
+			opts = append(opts, WithPathFormat(format))
+		}
+	}
+
+	for _, key := range n.keys {
+		node := n.fields[key]
+		switch key {
+		case "pathFormat":
+		case "ignore":
+			opts = append(opts, WithIgnorePaths(c.patterns(node, key, format, true)...))
+		case "include":
+			opts = append(opts, WithIncludePaths(c.patterns(node, key, format, true)...))
+		case "unordered":
+			opts = append(opts, WithUnorderedArrays(c.patterns(node, key, format, false)...))
+		case "arrayKeys":
+			opts = append(opts, c.arrayKeys(node, format)...)
+		case "tolerances":
+			opts = append(opts, c.tolerances(node, format)...)
+		case "arrayMode":
+			if v, ok := c.enum(node, key, "index", "lcs"); ok {
+				opts = append(opts, WithArrayDiffMode(ArrayDiffMode(v)))
+			}
+		case "moves":
+			if b, ok := c.boolean(node, key); ok && b {
+				opts = append(opts, WithMoveDetection())
+			}
+		case "patchTests":
+			if b, ok := c.boolean(node, key); ok && b {
+				opts = append(opts, WithPatchTests())
+			}
+		case "numbers":
+			if v, ok := c.enum(node, key, "float64", "exact", "literal"); ok {
+				opts = append(opts, WithNumberMode(NumberMode(v)))
+			}
+		case "order":
+			if v, ok := c.enum(node, key, "document", "path"); ok {
+				opts = append(opts, WithResultOrder(ResultOrder(v)))
+			}
+		case "detail":
+			if v, ok := c.enum(node, key, "full", "paths"); ok {
+				opts = append(opts, WithDetail(DetailLevel(v)))
+			}
+		case "maxDocumentSize":
+			if v, ok := c.integer(node, key); ok {
+				opts = append(opts, WithMaxDocumentSize(v))
+			}
+		case "maxDepth":
+			if v, ok := c.integer(node, key); ok {
+				opts = append(opts, WithMaxDepth(v))
+			}
+		case "maxChanges":
+			if v, ok := c.integer(node, key); ok {
+				opts = append(opts, WithMaxChanges(v))
+			}
+		default:
+			c.errorf(node.line, "未知的键 %q", key)
+		}
+	}
+	return opts
+}
+
+// arrayKeys 读取按标识字段配对的数组规则，每条规则包含 path 和 fields
+func (c *ruleChecker) arrayKeys(n *ruleNode, f PathFormat) []Option {
+	if !c.expectKind(n, ruleList, "arrayKeys") {
+		return nil
+	}
+	var opts []Option
+	for _, item := range n.items {
+		if !c.fields(item, "arrayKeys 的元素", "path", "fields") {
+			continue
+		}
+		pathNode, fieldsNode := item.fields["path"], item.fields["fields"]
+		if pathNode == nil || fieldsNode == nil {
+			c.errorf(item.line, "arrayKeys 的元素必须包含 path 和 fields")
+			continue
+		}
+		pattern, ok := c.pattern(pathNode, "arrayKeys.path", f, false)
+		fields := c.stringList(fieldsNode, "arrayKeys.fields")
+		if ok && len(fields) > 0 {
+			opts = append(opts, WithArrayKey(pattern, fields...))
+		}
+	}
+	return opts
+}
+
+// tolerances 读取数字容差规则，每条规则包含 path 以及 absolute、relative、ulp 中的至少一个
+func (c *ruleChecker) tolerances(n *ruleNode, f PathFormat) []Option {
+	if !c.expectKind(n, ruleList, "tolerances") {
+		return nil
+	}
+	var opts []Option
+	for _, item := range n.items {
+		if !c.fields(item, "tolerances 的元素", "path", "absolute", "relative", "ulp") {
+			continue
+		}
+		pathNode := item.fields["path"]
+		if pathNode == nil || len(item.keys) < 2 {
+			c.errorf(item.line, "tolerances 的元素必须包含 path 以及 absolute、relative、ulp 中的至少一个")
+			continue
+		}
+		pattern, ok := c.pattern(pathNode, "tolerances.path", f, false)
+
+		var tol Tolerance
+		if node := item.fields["absolute"]; node != nil {
+			v, valid := c.number(node, "tolerances.absolute")
+			tol.Absolute, ok = v, ok && valid
+		}
+		if node := item.fields["relative"]; node != nil {
+			v, valid := c.number(node, "tolerances.relative")
+			tol.Relative, ok = v, ok && valid
+		}
+		if node := item.fields["ulp"]; node != nil {
+			v, valid := c.integer(node, "tolerances.ulp")
+			tol.ULP, ok = uint64(v), ok && valid
+		}
+		if ok {
+			opts = append(opts, WithTolerance(pattern, tol))
+		}
+	}
+	return opts
+}
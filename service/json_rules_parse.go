@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ruleKind 表示规则文件中值的种类
+type ruleKind int
+
+const (
+	ruleScalar ruleKind = iota // 字符串、数字、布尔值或 null
+	ruleMap                    // 映射
+	ruleList                   // 序列
+)
+
+// ruleNode 表示规则文件解析后的一个值，记录所在的行号用于报告错误
+type ruleNode struct {
+	line   int
+	kind   ruleKind
+	value  interface{} // 标量的值：string、float64、bool 或 nil
+	keys   []string    // 映射的键，按在文件中出现的顺序排列
+	fields map[string]*ruleNode
+	items  []*ruleNode
+	yaml   *yaml.Node // YAML 规则文件中对应的节点，JSON 规则文件中为 nil
+}
+
+// ruleErrorf 返回只有一处错误的 RuleErrors
+func ruleErrorf(line int, format string, args ...interface{}) error {
+	return RuleErrors{{Line: line, Msg: fmt.Sprintf(format, args...)}}
+}
+
+// parseRuleDocuments 解析规则文件中的所有文档，第一个非空白字符为 { 时按 JSON 解析，否则按 YAML 解析
+func parseRuleDocuments(data []byte) ([]*ruleNode, error) {
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		node, err := parseJSONRules(data)
+		if err != nil {
+			return nil, err
+		}
+		return []*ruleNode{node}, nil
+	}
+	return parseYAMLRules(data)
+}
+
+// parseJSONRules 按 JSON 解析规则文件，同时记录每个值所在的行号
+func parseJSONRules(data []byte) (*ruleNode, error) {
+	var newlines []int
+	for i, c := range data {
+		if c == '\n' {
+			newlines = append(newlines, i)
+		}
+	}
+	lineOf := func(offset int64) int {
+		return sort.SearchInts(newlines, int(offset)) + 1
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeRuleNode(dec, lineOf)
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			return node, nil
+		}
+		if err == nil {
+			return nil, ruleErrorf(lineOf(dec.InputOffset()), "JSON 之后有多余的内容")
+		}
+	}
+
+	var ruleErrs RuleErrors
+	if errors.As(err, &ruleErrs) {
+		return nil, err
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return nil, ruleErrorf(lineOf(syntaxErr.Offset), "无效的 JSON: %v", err)
+	}
+	return nil, ruleErrorf(lineOf(dec.InputOffset()), "无效的 JSON: %v", err)
+}
+
+// decodeRuleNode 从解码器中读取一个值
+func decodeRuleNode(dec *json.Decoder, lineOf func(int64) int) (*ruleNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	line := lineOf(dec.InputOffset())
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			node := &ruleNode{line: line, kind: ruleList}
+			for dec.More() {
+				item, err := decodeRuleNode(dec, lineOf)
+				if err != nil {
+					return nil, err
+				}
+				node.items = append(node.items, item)
+			}
+			_, err := dec.Token()
+			return node, err
+		}
+
+		node := &ruleNode{line: line, kind: ruleMap, fields: make(map[string]*ruleNode)}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			keyLine := lineOf(dec.InputOffset())
+			if _, exists := node.fields[key]; exists {
+				return nil, ruleErrorf(keyLine, "重复的键 %q", key)
+			}
+			child, err := decodeRuleNode(dec, lineOf)
+			if err != nil {
+				return nil, err
+			}
+			child.line = keyLine
+			node.keys = append(node.keys, key)
+			node.fields[key] = child
+		}
+		_, err := dec.Token()
+		return node, err
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return nil, ruleErrorf(line, "无效的数字 %s", t)
+		}
+		return &ruleNode{line: line, kind: ruleScalar, value: f}, nil
+	}
+	return &ruleNode{line: line, kind: ruleScalar, value: tok}, nil
+}
+
+// parseYAMLRules 使用 gopkg.in/yaml.v3 解析规则文件中的每个文档，空文档被跳过
+func parseYAMLRules(data []byte) ([]*ruleNode, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []*ruleNode
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, yamlRuleError(err)
+		}
+		if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+			continue
+		}
+		c := &yamlConverter{nodes: make(map[*yaml.Node]*ruleNode)}
+		node, err := c.convert(doc.Content[0])
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, node)
+	}
+}
+
+// yamlErrorLine 匹配 yaml.v3 错误信息中的行号
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// yamlRuleError 把 yaml.v3 的语法错误转换为带行号的 RuleErrors
+func yamlRuleError(err error) error {
+	msg := err.Error()
+	line := 1
+	if m := yamlErrorLine.FindStringSubmatch(msg); m != nil {
+		line, _ = strconv.Atoi(m[1])
+		msg = msg[len(m[0]):]
+	}
+	return ruleErrorf(line, "无效的 YAML: %s", strings.TrimPrefix(msg, "yaml: "))
+}
+
+// yamlConverter 把 YAML 节点转换为规则节点，同一个节点只转换一次，别名直接复用其指向的节点
+type yamlConverter struct {
+	nodes map[*yaml.Node]*ruleNode
+}
+
+// convert 转换一个 YAML 节点
+func (c *yamlConverter) convert(n *yaml.Node) (*ruleNode, error) {
+	if n.Kind == yaml.AliasNode {
+		target, err := c.convert(n.Alias)
+		if err != nil {
+			return nil, err
+		}
+		// 错误报告在使用别名的位置
+		alias := *target
+		alias.line = n.Line
+		return &alias, nil
+	}
+	if node, exists := c.nodes[n]; exists {
+		return node, nil
+	}
+
+	node := &ruleNode{line: n.Line, yaml: n}
+	c.nodes[n] = node
+	switch n.Kind {
+	case yaml.ScalarNode:
+		node.kind = ruleScalar
+		var v interface{}
+		if err := n.Decode(&v); err != nil {
+			return nil, ruleErrorf(n.Line, "无效的值 %q: %v", n.Value, err)
+		}
+		switch t := v.(type) {
+		case nil, bool, float64, string:
+			node.value = t
+		case int:
+			node.value = float64(t)
+		case int64:
+			node.value = float64(t)
+		case uint64:
+			node.value = float64(t)
+		default:
+			// 时间戳等其他类型按原文作为字符串使用
+			node.value = n.Value
+		}
+	case yaml.SequenceNode:
+		node.kind = ruleList
+		for _, item := range n.Content {
+			child, err := c.convert(item)
+			if err != nil {
+				return nil, err
+			}
+			node.items = append(node.items, child)
+		}
+	case yaml.MappingNode:
+		node.kind = ruleMap
+		node.fields = make(map[string]*ruleNode)
+		var merges []*yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Kind == yaml.ScalarNode && key.Tag == "!!merge" {
+				merges = append(merges, value)
+				continue
+			}
+			if key.Kind != yaml.ScalarNode {
+				return nil, ruleErrorf(key.Line, "映射的键应为字符串")
+			}
+			if _, exists := node.fields[key.Value]; exists {
+				return nil, ruleErrorf(key.Line, "重复的键 %q", key.Value)
+			}
+			child, err := c.convert(value)
+			if err != nil {
+				return nil, err
+			}
+			// 与 JSON 规则文件一样，值的错误报告在键所在的行
+			if child.yaml == value {
+				line := *child
+				line.line = key.Line
+				child = &line
+			}
+			node.keys = append(node.keys, key.Value)
+			node.fields[key.Value] = child
+		}
+		if err := c.merge(node, merges); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ruleErrorf(n.Line, "无法识别的 YAML 节点")
+	}
+	return node, nil
+}
+
+// merge 按合并键 << 把其他映射中的成员加入 node，node 中已有的键优先，序列中靠前的映射优先
+func (c *yamlConverter) merge(node *ruleNode, merges []*yaml.Node) error {
+	for _, m := range merges {
+		sources := []*yaml.Node{m}
+		if m.Kind == yaml.SequenceNode {
+			sources = m.Content
+		}
+		for _, src := range sources {
+			from, err := c.convert(src)
+			if err != nil {
+				return err
+			}
+			if from.kind != ruleMap {
+				return ruleErrorf(src.Line, "合并键 << 的值应为映射或映射的序列")
+			}
+			for _, k := range from.keys {
+				if _, exists := node.fields[k]; !exists {
+					node.keys = append(node.keys, k)
+					node.fields[k] = from.fields[k]
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseRules_YAML 测试从 YAML 规则文件加载命名规则集并用于比较
+func TestParseRules_YAML(t *testing.T) {
+	data := `
+# 订单接口的比较规则
+rulesets:
+  orders:
+    ignore:
+      - "**.updatedAt"
+      - $.items[?(@.deleted==true)]
+    arrayKeys:
+      - path: items
+        fields: [sku]
+    tolerances:
+      - {path: "items[*].price", absolute: 0.01}
+    unordered: [tags]
+  strict:
+    detail: paths   # 只关心路径
+    maxChanges: 1
+`
+	rules, err := ParseRules([]byte(data))
+	if err != nil {
+		t.Fatalf("解析规则文件失败: %v", err)
+	}
+	if names := rules.Names(); !reflect.DeepEqual(names, []string{"orders", "strict"}) {
+		t.Errorf("规则集名称为%v，预期[orders strict]", names)
+	}
+
+	json1 := `{"updatedAt":1,"tags":["a","b"],"items":[{"sku":"x","price":1.00},{"sku":"y","price":2,"deleted":true}]}`
+	json2 := `{"updatedAt":2,"tags":["b","a"],"items":[{"sku":"y","price":3,"deleted":true},{"sku":"x","price":1.005}]}`
+
+	opts, err := rules.Options("orders")
+	if err != nil {
+		t.Fatalf("获取规则集失败: %v", err)
+	}
+	result, err := NewJSONDiffService().Compare(context.Background(), json1, json2, opts...)
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+	if len(result.Changes) != 0 {
+		t.Errorf("按orders规则比较应该相等，实际差异为%+v", result.Changes)
+	}
+
+	opts, _ = rules.Options("strict")
+	result, err = NewJSONDiffService(opts...).CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较失败: %v", err)
+	}
+	if len(result.Changes) != 1 || !result.Truncated || result.Changes[0].OldValue != nil {
+		t.Errorf("按strict规则应该只报告一处不含值的差异，实际为%+v", result)
+	}
+
+	if _, err := rules.Options("missing"); err == nil {
+		t.Errorf("不存在的规则集应该返回错误")
+	}
+}
+
+// TestParseRules_JSON 测试 JSON 格式的规则文件与等价的 YAML 规则文件结果相同
+func TestParseRules_JSON(t *testing.T) {
+	jsonRules := `{"rulesets": {"users": {
+		"pathFormat": "pointer",
+		"ignore": ["/meta"],
+		"arrayKeys": [{"path": "/users", "fields": ["id"]}],
+		"arrayMode": "lcs",
+		"numbers": "exact",
+		"order": "path"
+	}}}`
+	yamlRules := `rulesets:
+  users:
+    pathFormat: pointer
+    ignore: /meta
+    arrayKeys:
+    - path: /users
+      fields: id
+    arrayMode: lcs
+    numbers: exact
+    order: path
+`
+	json1 := `{"meta":{"v":1},"users":[{"id":1,"n":1.0},{"id":2,"n":"a"}]}`
+	json2 := `{"meta":{"v":2},"users":[{"id":2,"n":"b"},{"id":1,"n":1}]}`
+
+	var results []JSONDiffResult
+	for _, data := range []string{jsonRules, yamlRules} {
+		rules, err := ParseRules([]byte(data))
+		if err != nil {
+			t.Fatalf("解析规则文件失败: %v", err)
+		}
+		opts, err := rules.Options("users")
+		if err != nil {
+			t.Fatalf("获取规则集失败: %v", err)
+		}
+		result, err := NewJSONDiffService(opts...).CompareJSON(json1, json2)
+		if err != nil {
+			t.Fatalf("比较失败: %v", err)
+		}
+		results = append(results, result)
+	}
+	if len(results[0].Changes) != 1 || results[0].Changes[0].Path != "/users/0/n" {
+		t.Errorf("应该只有/users/0/n一处差异，实际为%+v", results[0].Changes)
+	}
+	if !reflect.DeepEqual(results[0], results[1]) {
+		t.Errorf("JSON与YAML规则的结果不同：%+v 与 %+v", results[0], results[1])
+	}
+}
+
+// TestParseRules_YAMLFeatures 测试锚点、别名、合并键、多行字符串、跨行的流式集合、yes/no 布尔值和多文档
+func TestParseRules_YAMLFeatures(t *testing.T) {
+	data := `
+defaults: &defaults
+  ignore: &noise ["**.updatedAt"]
+  moves: yes
+---
+rulesets:
+  base: &base
+    ignore: *noise
+    unordered: [
+      tags,
+      labels
+    ]
+  extended:
+    <<: *base
+    patchTests: on
+    include: >-
+      items
+---
+rulesets:
+  literal:
+    ignore: |-
+      $.items[?(@.deleted==true)]
+`
+	_, err := ParseRules([]byte(data))
+	var errs RuleErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 2 || !strings.Contains(errs[0].Msg, "defaults") {
+		t.Fatalf("第一个文档中未知的键应该在第2行报告，实际为%v", err)
+	}
+
+	rules, err := ParseRules([]byte(strings.Replace(data, "defaults: &defaults", "rulesets:\n x: &defaults", 1)))
+	if err != nil {
+		t.Fatalf("解析规则文件失败: %v", err)
+	}
+	if names := rules.Names(); !reflect.DeepEqual(names, []string{"x", "base", "extended", "literal"}) {
+		t.Errorf("规则集名称为%v，预期[x base extended literal]", names)
+	}
+
+	json1 := `{"updatedAt":1,"tags":["a","b"],"labels":["x","y"],"items":[{"id":1,"deleted":true}]}`
+	json2 := `{"updatedAt":2,"tags":["b","a"],"labels":["y","x"],"items":[{"id":2,"deleted":true}]}`
+	// x 启用了移动检测，两个数组的交换各报告为一处移动
+	for name, expected := range map[string]int{"x": 3, "base": 1, "extended": 1, "literal": 5} {
+		opts, err := rules.Options(name)
+		if err != nil {
+			t.Fatalf("获取规则集失败: %v", err)
+		}
+		result, err := NewJSONDiffService(opts...).CompareJSON(json1, json2)
+		if err != nil {
+			t.Fatalf("比较失败: %v", err)
+		}
+		if len(result.Changes) != expected {
+			t.Errorf("按%s规则比较应该有%d处差异，实际为%+v", name, expected, result.Changes)
+		}
+	}
+
+	// 多个文档中的规则集名称不能重复
+	_, err = ParseRules([]byte("rulesets:\n  a: {}\n---\nrulesets:\n  a: {}\n"))
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 5 {
+		t.Errorf("重复的规则集应该在第5行报告，实际为%v", err)
+	}
+}
+
+// TestParseRules_Errors 测试规则文件中的所有错误都带有行号报告
+func TestParseRules_Errors(t *testing.T) {
+	data := `rulesets:
+  orders:
+    ignore: ["a[", "$.x[?(@.y ==)]"]
+    unordered: [$.tags]
+    arrayMode: fuzzy
+    tolerance:
+      - path: price
+    tolerances:
+      - path: price
+        absolut: 0.1
+    maxDepth: -1
+`
+	_, err := ParseRules([]byte(data))
+	var errs RuleErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("应该返回RuleErrors，实际为%v", err)
+	}
+	lines := make([]int, len(errs))
+	for i, e := range errs {
+		lines[i] = e.Line
+	}
+	if expected := []int{3, 3, 4, 5, 6, 10, 11}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("错误所在的行为%v，预期%v\n%v", lines, expected, err)
+	}
+	if !strings.Contains(err.Error(), `第 6 行: 未知的键 "tolerance"`) {
+		t.Errorf("错误信息应该包含未知的键及其行号，实际为%v", err)
+	}
+
+	// JSON 规则文件中的错误同样带有行号
+	_, err = ParseRules([]byte("{\"rulesets\": {\n  \"a\": {\n    \"moves\": \"yes\"\n  }\n}}"))
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 3 {
+		t.Errorf("应该报告第3行的错误，实际为%v", err)
+	}
+
+	// 语法错误
+	for _, data := range []string{"rulesets:\n  a: [1,\n", "{\"rulesets\": {\"a\": {}, \"a\": {}}}", "rulesets:\n\tbad: 1\n"} {
+		if _, err := ParseRules([]byte(data)); err == nil {
+			t.Errorf("%q 应该返回错误", data)
+		}
+	}
+}
+
+// TestLoadRuleFile 测试从文件加载规则
+func TestLoadRuleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte("rulesets:\n  a:\n    moves: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadRuleFile(path)
+	if err != nil {
+		t.Fatalf("加载规则文件失败: %v", err)
+	}
+	if opts, err := rules.Options("a"); err != nil || len(opts) != 1 {
+		t.Errorf("规则集a应该有1个选项，实际为%d个，错误为%v", len(opts), err)
+	}
+
+	if _, err := LoadRuleFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("文件不存在时应该返回错误")
+	}
+}
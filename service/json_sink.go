@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ChangeSink 在比较过程中逐条接收差异，不必把所有差异保存在 JSONDiffResult 中。
+// Record 返回 ErrStopComparison 时比较正常结束，返回其他错误时比较中断并返回该错误
+type ChangeSink interface {
+	Record(c Change) error
+}
+
+// ChangeSinkFunc 把函数适配为 ChangeSink
+type ChangeSinkFunc func(c Change) error
+
+// Record 调用函数本身
+func (f ChangeSinkFunc) Record(c Change) error {
+	return f(c)
+}
+
+// ErrStopComparison 由 ChangeSink 返回，表示已经得到需要的差异，比较可以提前结束
+var ErrStopComparison = errors.New("停止比较")
+
+// Record 把差异追加到结果中，同时维护旧版的 Added、Removed、Changed 和 Moved 字段；
+// JSONDiffResult 是 Compare 使用的 ChangeSink
+func (r *JSONDiffResult) Record(c Change) error {
+	if r.Changed == nil {
+		r.Changed = make(map[string]string)
+	}
+	r.appendChange(c)
+	return nil
+}
+
+// StopAfter 返回只把前 n 条差异交给 sink 的 ChangeSink，收到第 n 条差异后返回 ErrStopComparison 结束比较；
+// n <= 0 时不交出任何差异，收到第一条差异时直接结束比较；sink 为 nil 时只计数，可以用于判断差异是否达到 n 条
+func StopAfter(n int, sink ChangeSink) ChangeSink {
+	count := 0
+	return ChangeSinkFunc(func(c Change) error {
+		if n <= 0 {
+			return ErrStopComparison
+		}
+		count++
+		if sink != nil {
+			if err := sink.Record(c); err != nil {
+				return err
+			}
+		}
+		if count >= n {
+			return ErrStopComparison
+		}
+		return nil
+	})
+}
+
+// CompareTo 比较两个JSON字符串，把发现的每条差异依次交给 sink，而不是保存在 JSONDiffResult 中；
+// sink 返回 ErrStopComparison 或差异数量达到 WithMaxChanges 的上限时提前结束，不返回错误。
+// 启用移动检测或按路径排序时需要先得到所有差异，这时差异先在内存中收集，比较结束后再依次交给 sink
+func (s *jsonDiffServiceImpl) CompareTo(ctx context.Context, json1, json2 string, sink ChangeSink, opts ...Option) error {
+	config := s.config.with(opts)
+	return config.compareStrings(ctx, json1, json2, sink)
+}
+
+// Equal 判断两个JSON字符串在当前配置下是否没有差异，发现第一处差异后立即结束比较
+func (s *jsonDiffServiceImpl) Equal(ctx context.Context, json1, json2 string, opts ...Option) (bool, error) {
+	config := s.config.with(opts)
+	if err := config.checkSize(len(json1), len(json2)); err != nil {
+		return false, err
+	}
+	order := config.newKeyOrder()
+	obj1, obj2, err := config.parseJSONPair(json1, json2, order)
+	if err != nil {
+		return false, err
+	}
+	if err := config.checkDepth(obj1, obj2); err != nil {
+		return false, err
+	}
+
+	// 启用移动检测时数组仍按 LCS 或标识字段比较，元素顺序的变化照常报告为差异；
+	// 子树移动的配对只是把已有的新增和移除合并为移动，不影响是否相等，因此不必收集所有差异
+	equal := true
+	d, err := config.newDiffer(ctx, order, ChangeSinkFunc(func(Change) error {
+		equal = false
+		return ErrStopComparison
+	}), obj1, obj2)
+	if err != nil {
+		return false, err
+	}
+	d.compareValues("", obj1, obj2)
+	if err := d.stopError(); err != nil {
+		return false, err
+	}
+	return equal, nil
+}
+
+// compareStrings 解析两个JSON字符串并把差异交给 sink
+func (c *diffConfig) compareStrings(ctx context.Context, json1, json2 string, sink ChangeSink) error {
+	if err := c.checkSize(len(json1), len(json2)); err != nil {
+		return err
+	}
+	order := c.newKeyOrder()
+	obj1, obj2, err := c.parseJSONPair(json1, json2, order)
+	if err != nil {
+		return err
+	}
+	return c.compareToSink(ctx, obj1, obj2, order, sink)
+}
+
+// compareToSink 比较两个解析后的文档并把差异交给 sink
+func (c *diffConfig) compareToSink(ctx context.Context, obj1, obj2 interface{}, order keyOrder, sink ChangeSink) error {
+	if c.moves || c.order == OrderPath {
+		result, err := c.compareTrees(ctx, obj1, obj2, order)
+		if err != nil {
+			return err
+		}
+		for _, change := range result.Changes {
+			if err := sink.Record(change); err != nil {
+				return stopError(err)
+			}
+		}
+		return nil
+	}
+
+	if err := c.checkDepth(obj1, obj2); err != nil {
+		return err
+	}
+	d, err := c.newDiffer(ctx, order, c.detailSink(sink), obj1, obj2)
+	if err != nil {
+		return err
+	}
+	d.compareValues("", obj1, obj2)
+	return d.stopError()
+}
+
+// detailSink 按 DetailPaths 去掉差异中的旧值和新值后再交给 sink
+func (c *diffConfig) detailSink(sink ChangeSink) ChangeSink {
+	if c.detail != DetailPaths {
+		return sink
+	}
+	return ChangeSinkFunc(func(change Change) error {
+		change.OldValue, change.NewValue = nil, nil
+		return sink.Record(change)
+	})
+}
+
+// stopError 返回比较结束的原因对应的错误，sink 要求停止时不是错误
+func (d *jsonDiffer) stopError() error {
+	if d.err == nil {
+		return nil
+	}
+	return stopError(fmt.Errorf("比较被中断: %w", d.err))
+}
+
+// stopError 把 ErrStopComparison 转换为 nil，其他错误原样返回
+func stopError(err error) error {
+	if errors.Is(err, ErrStopComparison) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestCompareTo_ResultSink 测试把差异结果作为 sink 与 Compare 得到相同的差异
+func TestCompareTo_ResultSink(t *testing.T) {
+	json1 := `{"a":1,"b":{"c":[1,2]},"d":{"x":{"y":1}},"e":true}`
+	json2 := `{"a":2,"b":{"c":[1]},"f":{"x":{"y":1}},"e":false}`
+	ctx := context.Background()
+
+	for _, opts := range [][]Option{
+		nil,
+		{WithDetail(DetailPaths)},
+		{WithMoveDetection(), WithResultOrder(OrderPath)},
+	} {
+		service := NewJSONDiffService(opts...)
+		expected, err := service.Compare(ctx, json1, json2)
+		if err != nil {
+			t.Fatalf("比较JSON时出错: %v", err)
+		}
+		var actual JSONDiffResult
+		if err := service.CompareTo(ctx, json1, json2, &actual); err != nil {
+			t.Fatalf("比较JSON时出错: %v", err)
+		}
+		if !reflect.DeepEqual(actual.Changes, expected.Changes) {
+			t.Errorf("sink收到的差异为%+v，预期%+v", actual.Changes, expected.Changes)
+		}
+		if !reflect.DeepEqual(actual.Changed, expected.Changed) {
+			t.Errorf("sink生成的变更描述为%v，预期%v", actual.Changed, expected.Changed)
+		}
+	}
+}
+
+// TestCompareTo_EarlyTermination 测试 sink 提前结束比较
+func TestCompareTo_EarlyTermination(t *testing.T) {
+	service := NewJSONDiffService()
+	ctx := context.Background()
+	json1 := `{"a":1,"b":2,"c":3,"d":4}`
+	json2 := `{"a":0,"b":0,"c":0,"d":0}`
+
+	var result JSONDiffResult
+	if err := service.CompareTo(ctx, json1, json2, StopAfter(2, &result)); err != nil {
+		t.Fatalf("提前结束不应该返回错误: %v", err)
+	}
+	if paths := result.ChangedPaths(); !reflect.DeepEqual(paths, []string{"a", "b"}) {
+		t.Errorf("应该只收到前两处差异，实际为%v", paths)
+	}
+
+	calls := 0
+	errSink := errors.New("写入失败")
+	err := service.CompareTo(ctx, json1, json2, ChangeSinkFunc(func(Change) error {
+		calls++
+		return errSink
+	}))
+	if !errors.Is(err, errSink) || calls != 1 {
+		t.Errorf("sink返回错误后应该停止并返回该错误，实际调用%d次，错误为%v", calls, err)
+	}
+
+	// 按路径排序时先收集所有差异，再交给 sink 时同样可以提前结束
+	result = JSONDiffResult{}
+	if err := service.CompareTo(ctx, json1, json2, StopAfter(1, &result), WithResultOrder(OrderPath)); err != nil || len(result.Changes) != 1 {
+		t.Errorf("应该只收到一处差异，实际为%+v，错误为%v", result.Changes, err)
+	}
+
+	// n <= 0 时不交出任何差异
+	for _, n := range []int{0, -1} {
+		result = JSONDiffResult{}
+		if err := service.CompareTo(ctx, json1, json2, StopAfter(n, &result)); err != nil || len(result.Changes) != 0 {
+			t.Errorf("StopAfter(%d)不应该交出差异，实际为%+v，错误为%v", n, result.Changes, err)
+		}
+	}
+}
+
+// TestEqual 测试发现第一处差异即结束的相等判断
+func TestEqual(t *testing.T) {
+	service := NewJSONDiffService(WithIgnorePaths("ts"), WithMoveDetection(), WithArrayKey("k[*]", "id"))
+	ctx := context.Background()
+
+	tests := []struct {
+		json1, json2 string
+		expected     bool
+	}{
+		{`{"a":[1,2],"ts":1}`, `{"ts":2,"a":[1,2]}`, true},
+		{`{"a":[1,2]}`, `{"a":[2,1]}`, false},
+		{`null`, `null`, true},
+		// 启用移动检测时按标识字段配对的元素顺序变化、数组内的移动和子树的移动都是差异
+		{`{"k":[{"id":1},{"id":2}]}`, `{"k":[{"id":2},{"id":1}]}`, false},
+		{`[null,1]`, `[1,null]`, false},
+		{`{"x":{"p":[1]}}`, `{"y":{"p":[1]}}`, false},
+	}
+	for _, tt := range tests {
+		equal, err := service.Equal(ctx, tt.json1, tt.json2)
+		if err != nil {
+			t.Fatalf("比较JSON时出错: %v", err)
+		}
+		if equal != tt.expected {
+			t.Errorf("Equal(%s, %s) = %v，预期%v", tt.json1, tt.json2, equal, tt.expected)
+		}
+		// 与 Compare 的结果一致
+		diff, err := service.Compare(ctx, tt.json1, tt.json2)
+		if err != nil {
+			t.Fatalf("比较JSON时出错: %v", err)
+		}
+		if (len(diff.Changes) == 0) != equal {
+			t.Errorf("Equal(%s, %s) = %v，Compare 的差异为%+v", tt.json1, tt.json2, equal, diff.Changes)
+		}
+	}
+
+	if _, err := service.Equal(ctx, `{`, `{}`); err == nil {
+		t.Errorf("无效的JSON应该返回错误")
+	}
+}
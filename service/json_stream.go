@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// StreamSummary 表示一次流式比较的汇总信息
+type StreamSummary struct {
+	Changes   int  // 交给回调的差异数量
+	Truncated bool // 差异数量达到 WithMaxChanges 的上限，比较提前结束
+}
+
+// errStreamHalted 表示流式比较因为差异数量达到上限、上下文取消或回调要求停止而停止
+var errStreamHalted = errors.New("流式比较已停止")
+
+// CompareStream 以流的方式比较两个 io.Reader 中的JSON文档，适合无法整体读入内存的大文件。
+// 两个文档按 json.Decoder 的标记同步遍历，每发现一处差异就调用一次 fn，回调返回错误时停止比较并返回该错误，
+// 返回 ErrStopComparison 时正常结束；需要通过通道消费差异时，可以在回调中把差异发送到通道。
+//
+// 只有在必须时才把子树读入内存：对象成员的顺序不一致时，先出现的成员在另一侧出现之前暂存；
+// 类型不同的值、按标识字段配对、忽略顺序或使用 LCS 策略比较的数组整体读入后按普通方式比较；
+// 被忽略的子树直接跳过。差异按发现的顺序报告，数组长度变更在数组的所有元素之后报告，
+// 因此顺序可能与 Compare 不同，但差异的集合相同。
+//
+// 流式比较不支持移动检测、按路径排序结果以及 JSONPath 忽略或包含规则，这些选项需要完整的文档，
+// 设置时返回错误；WithMaxDocumentSize 和 WithMaxDepth 在读取过程中检查
+func (s *jsonDiffServiceImpl) CompareStream(ctx context.Context, r1, r2 io.Reader, fn func(Change) error, opts ...Option) (StreamSummary, error) {
+	config := s.config.with(opts)
+	if err := config.checkStreamable(); err != nil {
+		return StreamSummary{}, err
+	}
+	// 没有 JSONPath 表达式时编译不会失败
+	d, _ := config.newDiffer(ctx, nil, config.detailSink(ChangeSinkFunc(fn)))
+
+	sd := &streamDiffer{d: d}
+	for i, r := range []io.Reader{r1, r2} {
+		sd.limits[i] = &limitedReader{r: r, limit: config.maxBytes, doc: i}
+		sd.decs[i] = json.NewDecoder(sd.limits[i])
+		if config.numbers != NumberFloat64 {
+			sd.decs[i].UseNumber()
+		}
+	}
+
+	err := sd.run()
+	summary := StreamSummary{Changes: d.recorded, Truncated: d.truncated}
+	if d.err != nil {
+		return summary, d.stopError()
+	}
+	return summary, err
+}
+
+// checkStreamable 检查配置能否用于流式比较
+func (c *diffConfig) checkStreamable() error {
+	if c.moves {
+		return errors.New("流式比较不支持移动检测")
+	}
+	if c.order == OrderPath {
+		return errors.New("流式比较不支持按路径排序结果")
+	}
+	for _, patterns := range [][]string{c.ignore, c.include} {
+		for _, pattern := range patterns {
+			if isJSONPath(pattern) {
+				return fmt.Errorf("流式比较不支持 JSONPath 表达式 %q", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// streamDiffer 同步读取两个文档的标记并比较
+type streamDiffer struct {
+	d      *jsonDiffer
+	decs   [2]*json.Decoder
+	limits [2]*limitedReader
+	depth  int // 当前所在的对象和数组的嵌套层数
+}
+
+// bufferedValue 表示读入内存的值及其中对象成员的顺序
+type bufferedValue struct {
+	value interface{}
+	order keyOrder
+}
+
+// run 比较两个文档，并检查文档之后只有空白
+func (s *streamDiffer) run() error {
+	t1, err := s.token(0)
+	if err != nil {
+		return err
+	}
+	t2, err := s.token(1)
+	if err != nil {
+		return err
+	}
+	if err := s.compare("", t1, t2); err != nil {
+		if err == errStreamHalted {
+			return nil
+		}
+		return err
+	}
+	for i, dec := range s.decs {
+		if _, err := dec.Token(); err != io.EOF {
+			if err == nil {
+				err = errors.New("值之后有多余的内容")
+			}
+			return s.fail(i, err)
+		}
+	}
+	return nil
+}
+
+// token 从第 i 个文档中读取下一个标记
+func (s *streamDiffer) token(i int) (json.Token, error) {
+	tok, err := s.decs[i].Token()
+	if err != nil {
+		return nil, s.fail(i, err)
+	}
+	return tok, nil
+}
+
+// fail 返回第 i 个文档的解析错误，超过大小上限时返回 ErrLimitExceeded
+func (s *streamDiffer) fail(i int, err error) error {
+	if s.limits[i].err != nil {
+		return s.limits[i].err
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("解析第%s个JSON失败: %v", ordinals[i], err)
+}
+
+// halted 检查比较是否应该停止
+func (s *streamDiffer) halted() error {
+	if s.d.err != nil || s.d.truncated {
+		return errStreamHalted
+	}
+	return nil
+}
+
+// compare 比较两个文档中位于同一路径的值，t1 和 t2 是两个值的第一个标记
+func (s *streamDiffer) compare(path string, t1, t2 json.Token) error {
+	if s.d.stopped() {
+		return errStreamHalted
+	}
+	if s.d.ignored(path) || s.d.included(path) == includeNone {
+		if err := s.skip(0, t1); err != nil {
+			return err
+		}
+		return s.skip(1, t2)
+	}
+
+	switch {
+	case t1 == json.Delim('{') && t2 == json.Delim('{'):
+		return s.compareObjects(path)
+	case t1 == json.Delim('[') && t2 == json.Delim('[') && s.streamable(path):
+		return s.compareArrays(path)
+	}
+
+	// 其余情况把两个值读入内存后按普通方式比较
+	b1, err := s.value(0, t1)
+	if err != nil {
+		return err
+	}
+	b2, err := s.value(1, t2)
+	if err != nil {
+		return err
+	}
+	s.inMemory(b1, b2, func() {
+		s.d.compareValues(path, b1.value, b2.value)
+	})
+	return s.halted()
+}
+
+// streamable 判断数组能否逐个元素流式比较：只有按下标比较的数组可以
+func (s *streamDiffer) streamable(path string) bool {
+	c := s.d.config
+	return !c.lcsArrays() && c.arrayKeyFields(path) == nil && !c.compiled().unordered.matches(path)
+}
+
+// compareObjects 比较两个对象的成员，两侧的成员顺序一致时逐个流式比较，
+// 不一致时暂存先出现的成员，等另一侧出现同名成员后在内存中比较
+func (s *streamDiffer) compareObjects(path string) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+
+	var pending [2]map[string]bufferedValue
+	var pendingKeys [2][]string
+	for {
+		var keys [2]string
+		var has [2]bool
+		for i, dec := range s.decs {
+			if !dec.More() {
+				continue
+			}
+			tok, err := s.token(i)
+			if err != nil {
+				return err
+			}
+			keys[i], has[i] = tok.(string), true
+		}
+		if !has[0] && !has[1] {
+			break
+		}
+
+		if has[0] && has[1] && keys[0] == keys[1] {
+			child := s.d.keyPath(path, keys[0])
+			t1, err := s.token(0)
+			if err != nil {
+				return err
+			}
+			t2, err := s.token(1)
+			if err != nil {
+				return err
+			}
+			if err := s.compare(child, t1, t2); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for i := range keys {
+			if !has[i] {
+				continue
+			}
+			child := s.d.keyPath(path, keys[i])
+			tok, err := s.token(i)
+			if err != nil {
+				return err
+			}
+			if s.d.ignored(child) || s.d.included(child) == includeNone {
+				if err := s.skip(i, tok); err != nil {
+					return err
+				}
+				continue
+			}
+			b, err := s.value(i, tok)
+			if err != nil {
+				return err
+			}
+
+			// 另一侧已经暂存了同名成员，在内存中比较
+			if other, ok := pending[1-i][keys[i]]; ok {
+				delete(pending[1-i], keys[i])
+				pair := [2]bufferedValue{}
+				pair[i], pair[1-i] = b, other
+				s.inMemory(pair[0], pair[1], func() {
+					s.d.compareValues(child, pair[0].value, pair[1].value)
+				})
+				if err := s.halted(); err != nil {
+					return err
+				}
+				continue
+			}
+			if pending[i] == nil {
+				pending[i] = make(map[string]bufferedValue)
+			}
+			if _, exists := pending[i][keys[i]]; !exists {
+				pendingKeys[i] = append(pendingKeys[i], keys[i])
+			}
+			pending[i][keys[i]] = b
+		}
+	}
+	if err := s.closeBoth(); err != nil {
+		return err
+	}
+
+	// 只在一侧出现的成员报告为移除或新增
+	for i, record := range []func(string, interface{}){s.d.recordRemoved, s.d.recordAdded} {
+		for _, k := range pendingKeys[i] {
+			b, ok := pending[i][k]
+			if !ok {
+				continue
+			}
+			s.inMemory(b, bufferedValue{}, func() {
+				record(s.d.keyPath(path, k), b.value)
+			})
+			if err := s.halted(); err != nil {
+				return err
+			}
+		}
+	}
+	return s.halted()
+}
+
+// compareArrays 按下标逐个比较两个数组的元素，较长数组多出的元素逐个读入后报告为移除或新增
+func (s *streamDiffer) compareArrays(path string) error {
+	if err := s.enter(); err != nil {
+		return err
+	}
+	defer s.leave()
+
+	var n [2]int
+	for {
+		more0, more1 := s.decs[0].More(), s.decs[1].More()
+		if !more0 && !more1 {
+			break
+		}
+		if more0 && more1 {
+			child := s.d.indexPath(path, n[0])
+			t1, err := s.token(0)
+			if err != nil {
+				return err
+			}
+			t2, err := s.token(1)
+			if err != nil {
+				return err
+			}
+			if err := s.compare(child, t1, t2); err != nil {
+				return err
+			}
+			n[0], n[1] = n[0]+1, n[1]+1
+			continue
+		}
+
+		i := 0
+		if more1 {
+			i = 1
+		}
+		child := s.d.indexPath(path, n[i])
+		n[i]++
+		tok, err := s.token(i)
+		if err != nil {
+			return err
+		}
+		if s.d.ignored(child) || s.d.included(child) == includeNone {
+			if err := s.skip(i, tok); err != nil {
+				return err
+			}
+			continue
+		}
+		b, err := s.value(i, tok)
+		if err != nil {
+			return err
+		}
+		s.inMemory(b, bufferedValue{}, func() {
+			if i == 0 {
+				s.d.recordRemoved(child, b.value)
+			} else {
+				s.d.recordAdded(child, b.value)
+			}
+		})
+		if err := s.halted(); err != nil {
+			return err
+		}
+	}
+	if err := s.closeBoth(); err != nil {
+		return err
+	}
+
+	if n[0] != n[1] {
+		s.d.record(Change{
+			Kind:     ChangeLengthChanged,
+			Path:     path,
+			OldValue: n[0],
+			NewValue: n[1],
+			OldType:  JSONArray,
+			NewType:  JSONArray,
+		})
+	}
+	return s.halted()
+}
+
+// closeBoth 读取两个文档中结束当前对象或数组的标记
+func (s *streamDiffer) closeBoth() error {
+	for i := range s.decs {
+		if _, err := s.token(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enter 进入一层对象或数组，超过嵌套层数限制时返回 ErrLimitExceeded
+func (s *streamDiffer) enter() error {
+	s.depth++
+	if limit := s.d.config.maxDepth; limit > 0 && s.depth > limit {
+		return fmt.Errorf("%w: JSON的嵌套层数超过 %d", ErrLimitExceeded, limit)
+	}
+	return nil
+}
+
+// leave 离开一层对象或数组
+func (s *streamDiffer) leave() {
+	s.depth--
+}
+
+// value 把第 i 个文档中从 tok 开始的值读入内存
+func (s *streamDiffer) value(i int, tok json.Token) (bufferedValue, error) {
+	if _, ok := tok.(json.Delim); !ok {
+		return bufferedValue{value: s.d.config.canonical(tok)}, nil
+	}
+	order := keyOrder{}
+	v, err := decodeOrderedFrom(s.decs[i], tok, order)
+	if err != nil {
+		return bufferedValue{}, s.fail(i, err)
+	}
+	if limit := s.d.config.maxDepth; limit > 0 && exceedsDepth(v, limit-s.depth) {
+		return bufferedValue{}, fmt.Errorf("%w: 第%s个JSON的嵌套层数超过 %d", ErrLimitExceeded, ordinals[i], limit)
+	}
+	return bufferedValue{value: s.d.config.canonical(v), order: order}, nil
+}
+
+// skip 跳过第 i 个文档中从 tok 开始的值，不读入内存
+func (s *streamDiffer) skip(i int, tok json.Token) error {
+	if _, ok := tok.(json.Delim); !ok {
+		return nil
+	}
+	nesting := 1
+	for nesting > 0 {
+		if limit := s.d.config.maxDepth; limit > 0 && s.depth+nesting > limit {
+			return fmt.Errorf("%w: 第%s个JSON的嵌套层数超过 %d", ErrLimitExceeded, ordinals[i], limit)
+		}
+		tok, err := s.token(i)
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			nesting++
+		case json.Delim('}'), json.Delim(']'):
+			nesting--
+		}
+	}
+	return nil
+}
+
+// inMemory 使用两个读入内存的值的成员顺序执行 fn
+func (s *streamDiffer) inMemory(b1, b2 bufferedValue, fn func()) {
+	order := b1.order
+	if len(b2.order) > 0 {
+		if order == nil {
+			order = keyOrder{}
+		}
+		for p, keys := range b2.order {
+			order[p] = keys
+		}
+	}
+	s.d.order = order
+	fn()
+	s.d.order = nil
+}
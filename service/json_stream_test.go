@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// collectStream 流式比较两个JSON字符串并收集所有差异
+func collectStream(service JSONDiffService, json1, json2 string, opts ...Option) ([]Change, StreamSummary, error) {
+	var changes []Change
+	summary, err := service.CompareStream(context.Background(), strings.NewReader(json1), strings.NewReader(json2),
+		func(c Change) error {
+			changes = append(changes, c)
+			return nil
+		}, opts...)
+	return changes, summary, err
+}
+
+// sortChanges 按路径和类型排列差异，用于比较顺序可能不同的两组差异
+func sortChanges(changes []Change) []Change {
+	sorted := append([]Change(nil), changes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Kind < sorted[j].Kind
+	})
+	return sorted
+}
+
+// TestCompareStream_MatchesCompare 测试流式比较与普通比较得到相同的差异集合
+func TestCompareStream_MatchesCompare(t *testing.T) {
+	tests := []struct {
+		name         string
+		json1, json2 string
+		opts         []Option
+	}{
+		{"成员顺序相同", `{"a":1,"b":{"c":[1,2,3]},"d":"x"}`, `{"a":2,"b":{"c":[1,5]},"d":"x","e":null}`, nil},
+		{"成员顺序不同", `{"a":1,"b":{"x":1,"y":[1]},"c":3,"z":0}`, `{"c":4,"b":{"y":[2],"x":1},"a":1,"n":{"k":1}}`, nil},
+		{"类型变化", `{"a":{"b":1},"c":[1],"d":null}`, `{"a":[1],"c":"s","d":{"e":1}}`, nil},
+		{"根为数组", `[1,{"a":1},[2]]`, `[1,{"a":2},[2],4,5]`, nil},
+		{"根为标量", `1`, `"1"`, nil},
+		{"忽略和包含", `{"ts":1,"items":[{"id":1,"v":1,"tmp":1}],"meta":{"x":1}}`, `{"meta":{"x":2},"items":[{"id":1,"v":2,"tmp":2}],"ts":2}`,
+			[]Option{WithIgnorePaths("ts", "items[*].tmp"), WithIncludePaths("items")}},
+		{"按标识字段配对", `{"list":[{"id":1,"v":1},{"id":2,"v":2}]}`, `{"list":[{"id":2,"v":3},{"id":1,"v":1}]}`,
+			[]Option{WithArrayKey("list", "id")}},
+		{"LCS和精确数字", `{"a":[1,2,3],"n":1.0}`, `{"a":[1,3],"n":1}`,
+			[]Option{WithArrayDiffMode(ArrayDiffLCS), WithNumberMode(NumberExact)}},
+		{"JSON Pointer", `{"a/b":[1],"c":{"d":1}}`, `{"c":{"d":2},"a/b":[]}`, []Option{WithPathFormat(PathPointer)}},
+	}
+
+	for _, tt := range tests {
+		service := NewJSONDiffService(tt.opts...)
+		expected, err := service.CompareJSON(tt.json1, tt.json2)
+		if err != nil {
+			t.Fatalf("%s: 比较JSON时出错: %v", tt.name, err)
+		}
+		actual, summary, err := collectStream(service, tt.json1, tt.json2)
+		if err != nil {
+			t.Fatalf("%s: 流式比较时出错: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(sortChanges(actual), sortChanges(expected.Changes)) {
+			t.Errorf("%s: 流式比较的差异为%+v，预期%+v", tt.name, actual, expected.Changes)
+		}
+		if summary.Changes != len(actual) || summary.Truncated {
+			t.Errorf("%s: 汇总信息为%+v，回调收到%d处差异", tt.name, summary, len(actual))
+		}
+	}
+}
+
+// TestCompareStream_EarlyStop 测试差异数量上限、回调错误和只保留路径
+func TestCompareStream_EarlyStop(t *testing.T) {
+	service := NewJSONDiffService()
+	json1 := `{"a":1,"b":2,"c":3,"d":4}`
+	json2 := `{"a":0,"b":0,"c":0,"d":0}`
+
+	changes, summary, err := collectStream(service, json1, json2, WithMaxChanges(2), WithDetail(DetailPaths))
+	if err != nil {
+		t.Fatalf("流式比较时出错: %v", err)
+	}
+	if len(changes) != 2 || !summary.Truncated || changes[0].OldValue != nil || changes[0].Path != "a" {
+		t.Errorf("应该只收到两处不含值的差异，实际为%+v，汇总为%+v", changes, summary)
+	}
+
+	errStop := errors.New("停止")
+	calls := 0
+	_, err = service.CompareStream(context.Background(), strings.NewReader(json1), strings.NewReader(json2),
+		func(Change) error {
+			calls++
+			return errStop
+		})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Errorf("回调返回错误后应该停止并返回该错误，实际调用%d次，错误为%v", calls, err)
+	}
+}
+
+// TestCompareStream_Errors 测试不支持的选项、无效的JSON和读取限制
+func TestCompareStream_Errors(t *testing.T) {
+	service := NewJSONDiffService()
+	for _, opts := range [][]Option{
+		{WithMoveDetection()},
+		{WithResultOrder(OrderPath)},
+		{WithIgnorePaths("$.a[?(@.b==1)]")},
+	} {
+		if _, _, err := collectStream(service, `{}`, `{}`, opts...); err == nil {
+			t.Errorf("不支持的选项应该返回错误")
+		}
+	}
+
+	for _, docs := range [][2]string{{`{"a":1`, `{"a":1}`}, {`{}`, `{} x`}, {`{"a":[1,}`, `{"a":[1]}`}, {``, `{}`}} {
+		if _, _, err := collectStream(service, docs[0], docs[1]); err == nil {
+			t.Errorf("%q 与 %q 应该返回解析错误", docs[0], docs[1])
+		}
+	}
+
+	deep := strings.Repeat(`{"a":`, 5) + "1" + strings.Repeat("}", 5)
+	if _, _, err := collectStream(service, deep, deep, WithMaxDepth(3)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("超过嵌套层数上限时应该返回ErrLimitExceeded，实际为%v", err)
+	}
+	if _, _, err := collectStream(service, `{"b":1}`, `{"a":`+deep+`}`, WithMaxDepth(3)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("暂存的子树超过嵌套层数上限时应该返回ErrLimitExceeded，实际为%v", err)
+	}
+	if _, _, err := collectStream(service, `{"a":"`+strings.Repeat("x", 100)+`"}`, `{}`, WithMaxDocumentSize(50)); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("超过大小上限时应该返回ErrLimitExceeded，实际为%v", err)
+	}
+}
+
+// TestCompareStream_LargeDocument 测试顺序一致的大文档逐个元素比较，被忽略的子树直接跳过
+func TestCompareStream_LargeDocument(t *testing.T) {
+	const n = 5000
+	doc := func(changed int) io.Reader {
+		r, w := io.Pipe()
+		go func() {
+			fmt.Fprint(w, `{"skip":[`)
+			for i := 0; i < n; i++ {
+				if i > 0 {
+					fmt.Fprint(w, ",")
+				}
+				fmt.Fprintf(w, `{"blob":"%d"}`, i*changed)
+			}
+			fmt.Fprint(w, `],"rows":[`)
+			for i := 0; i < n; i++ {
+				if i > 0 {
+					fmt.Fprint(w, ",")
+				}
+				v := i
+				if i == changed {
+					v = -1
+				}
+				fmt.Fprintf(w, `{"id":%d,"v":%d}`, i, v)
+			}
+			fmt.Fprint(w, `]}`)
+			w.Close()
+		}()
+		return r
+	}
+
+	var changes []Change
+	_, err := NewJSONDiffService(WithIgnorePaths("skip")).CompareStream(context.Background(), doc(1), doc(2),
+		func(c Change) error {
+			changes = append(changes, c)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("流式比较时出错: %v", err)
+	}
+	var paths []string
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	if !reflect.DeepEqual(paths, []string{"rows[1].v", "rows[2].v"}) {
+		t.Errorf("变更路径为%v，预期[rows[1].v rows[2].v]", paths)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/json"
+	"math"
+)
+
+// Tolerance 描述数字比较的容差，两个数字满足任一非零条件即视为相等
+type Tolerance struct {
+	Absolute float64 // 绝对误差：|a-b| <= Absolute
+	Relative float64 // 相对误差：|a-b| <= Relative × max(|a|, |b|)
+	ULP      uint64  // 两个 float64 之间相隔的可表示值个数不超过 ULP
+}
+
+// toleranceRule 表示一条按路径模式匹配的容差规则
+type toleranceRule struct {
+	pattern   string
+	tolerance Tolerance
+}
+
+// WithTolerance 为匹配 pattern 的数字设置比较容差，pattern 使用与忽略路径相同的语法，
+// 例如 "products[*].price"；多条规则匹配同一路径时使用最先添加的规则。
+// 容差只影响比较结果，生成的补丁仍然精确地把第一个JSON转换为第二个JSON
+func WithTolerance(pattern string, tolerance Tolerance) Option {
+	return func(c *diffConfig) {
+		c.tolerances = append(c.tolerances, toleranceRule{pattern: pattern, tolerance: tolerance})
+	}
+}
+
+// withinTolerance 检查两个数字是否在路径对应的容差范围内
+func (c *diffConfig) withinTolerance(path string, v1, v2 interface{}) bool {
+	if len(c.tolerances) == 0 {
+		return false
+	}
+	a, ok1 := numberValue(v1)
+	b, ok2 := numberValue(v2)
+	if !ok1 || !ok2 {
+		return false
+	}
+	if i := c.compiled().tolerances.first(path); i >= 0 {
+		return c.tolerances[i].tolerance.equal(a, b)
+	}
+	return false
+}
+
+// numberValue 把解析后的数字转换为 float64
+func numberValue(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// equal 判断两个数字是否在容差范围内
+func (t Tolerance) equal(a, b float64) bool {
+	diff := math.Abs(a - b)
+	if t.Absolute > 0 && diff <= t.Absolute {
+		return true
+	}
+	if t.Relative > 0 && diff <= t.Relative*math.Max(math.Abs(a), math.Abs(b)) {
+		return true
+	}
+	return t.ULP > 0 && ulpDistance(a, b) <= t.ULP
+}
+
+// ulpDistance 返回两个 float64 之间相隔的可表示值个数，0 与 -0 的距离为 0
+func ulpDistance(a, b float64) uint64 {
+	x, y := orderedBits(a), orderedBits(b)
+	if x < y {
+		x, y = y, x
+	}
+	return uint64(x) - uint64(y)
+}
+
+// orderedBits 把 float64 的位模式映射为与数值大小顺序一致的整数
+func orderedBits(f float64) int64 {
+	bits := int64(math.Float64bits(f))
+	if bits < 0 {
+		return math.MinInt64 - bits
+	}
+	return bits
+}
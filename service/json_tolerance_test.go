@@ -0,0 +1,74 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCompareJSON_Tolerance 测试按路径设置的数字容差
+func TestCompareJSON_Tolerance(t *testing.T) {
+	service := NewJSONDiffService(
+		WithTolerance("products[*].price", Tolerance{Absolute: 1e-6}),
+		WithTolerance("rating", Tolerance{Relative: 0.01}),
+		WithTolerance("latency", Tolerance{ULP: 4}),
+	)
+	json1 := `{"products":[{"price":10.99,"stock":5},{"price":5}],"rating":200,"latency":0.1,"total":10.99}`
+	json2 := `{"products":[{"price":10.990000001,"stock":5.000000001},{"price":5.1}],"rating":201.5,"latency":0.10000000000000003,"total":10.990000001}`
+
+	diff, err := service.CompareJSON(json1, json2)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	for _, path := range []string{"products[0].price", "rating", "latency"} {
+		if _, exists := diff.Changed[path]; exists {
+			t.Errorf("%s在容差范围内，不应被检测到变更", path)
+		}
+	}
+	// 没有容差规则的路径和超出容差的值仍然报告变更
+	for _, path := range []string{"products[0].stock", "products[1].price", "total"} {
+		if _, exists := diff.Changed[path]; !exists {
+			t.Errorf("%s应该被检测到变更", path)
+		}
+	}
+}
+
+// TestCompareJSON_ToleranceExactNumbers 测试精确比较数字时容差同样生效
+func TestCompareJSON_ToleranceExactNumbers(t *testing.T) {
+	service := NewJSONDiffService(WithNumberMode(NumberExact), WithTolerance("price", Tolerance{Absolute: 0.001}))
+	diff, err := service.CompareJSON(`{"price":10.99,"name":"a"}`, `{"price":10.9905,"name":1}`)
+	if err != nil {
+		t.Fatalf("比较JSON时出错: %v", err)
+	}
+	if _, exists := diff.Changed["price"]; exists {
+		t.Errorf("price在容差范围内，不应被检测到变更")
+	}
+	if _, exists := diff.Changed["name"]; !exists {
+		t.Errorf("类型不同的值不受容差影响")
+	}
+}
+
+// TestTolerance_Equal 测试各种容差条件
+func TestTolerance_Equal(t *testing.T) {
+	next := math.Nextafter(1, 2)
+	testCases := []struct {
+		tolerance Tolerance
+		a, b      float64
+		expected  bool
+	}{
+		{Tolerance{Absolute: 0.1}, 1, 1.05, true},
+		{Tolerance{Absolute: 0.1}, 1, 1.2, false},
+		{Tolerance{Relative: 0.01}, 1000, 1009, true},
+		{Tolerance{Relative: 0.01}, 1, 1.02, false},
+		{Tolerance{ULP: 1}, 1, next, true},
+		{Tolerance{ULP: 1}, 1, math.Nextafter(next, 2), false},
+		{Tolerance{ULP: 2}, math.Copysign(0, -1), math.SmallestNonzeroFloat64, true},
+		{Tolerance{ULP: 2}, -math.SmallestNonzeroFloat64, math.SmallestNonzeroFloat64, true},
+		{Tolerance{}, 1, next, false},
+	}
+
+	for _, tc := range testCases {
+		if actual := tc.tolerance.equal(tc.a, tc.b); actual != tc.expected {
+			t.Errorf("%+v.equal(%v, %v) = %v，预期%v", tc.tolerance, tc.a, tc.b, actual, tc.expected)
+		}
+	}
+}
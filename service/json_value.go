@@ -0,0 +1,100 @@
+package service
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// hashValue 计算JSON值的结构哈希，结构相同的值（对象键顺序无关）得到相同的哈希
+func hashValue(v interface{}) uint64 {
+	h := fnv.New64a()
+	writeHash(h, v)
+	return h.Sum64()
+}
+
+// hashValues 计算数组中每个元素的结构哈希
+func hashValues(values []interface{}) []uint64 {
+	hashes := make([]uint64, len(values))
+	for i, v := range values {
+		hashes[i] = hashValue(v)
+	}
+	return hashes
+}
+
+// hashWriter 是写入哈希所需的最小接口
+type hashWriter interface {
+	Write(p []byte) (int, error)
+}
+
+// writeHash 按照带类型标记的规范形式把值写入哈希
+func writeHash(h hashWriter, v interface{}) {
+	switch t := v.(type) {
+	case nil:
+		h.Write([]byte{'n'})
+	case bool:
+		if t {
+			h.Write([]byte{'t'})
+		} else {
+			h.Write([]byte{'f'})
+		}
+	case float64:
+		if t == 0 {
+			t = 0 // 统一 -0 与 0
+		}
+		h.Write([]byte{'d'})
+		h.Write([]byte(strconv.FormatUint(math.Float64bits(t), 16)))
+	case json.Number:
+		h.Write([]byte{'N'})
+		h.Write([]byte(t))
+	case string:
+		h.Write([]byte{'s'})
+		h.Write([]byte(strconv.Itoa(len(t))))
+		h.Write([]byte{':'})
+		h.Write([]byte(t))
+	case []interface{}:
+		h.Write([]byte{'['})
+		for _, e := range t {
+			writeHash(h, e)
+		}
+		h.Write([]byte{']'})
+	case map[string]interface{}:
+		h.Write([]byte{'{'})
+		for _, k := range sortedKeys(t) {
+			writeHash(h, k)
+			writeHash(h, t[k])
+		}
+		h.Write([]byte{'}'})
+	}
+}
+
+// sortedKeys 返回对象中按字典序排列的键
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// deepCopy 深拷贝解析后的JSON值
+func deepCopy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = deepCopy(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, e := range t {
+			a[i] = deepCopy(e)
+		}
+		return a
+	}
+	return v
+}